@@ -1,14 +1,13 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
 	"github.com/spf13/cobra"
 	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/output"
 	"lutonite.dev/gaps-cli/parser"
 )
 
@@ -22,7 +21,7 @@ const (
 )
 
 type AbsencesCmdOpts struct {
-	format   string
+	printer  output.Printer
 	year     uint
 	semester AbsencesPeriod
 	minRate  uint
@@ -40,27 +39,29 @@ var (
 				return fmt.Errorf("invalid semester: %s. Must be one of: all, ete, 1, 2", absencesOpts.semester)
 			}
 
-			cfg := buildTokenClientConfiguration()
+			cfg, err := buildTokenClientConfiguration(cmd.Context(), rememberToken)
+			if err != nil {
+				return err
+			}
 			absencesAction := gaps.NewAbsencesAction(cfg, absencesOpts.year)
 
-			absences, err := absencesAction.FetchAbsences()
+			absences, err := absencesAction.FetchAbsencesContext(cmd.Context())
 			if err != nil {
 				return fmt.Errorf("couldn't fetch absences: %w", err)
 			}
 
-			if absencesOpts.format == "json" {
-				return json.NewEncoder(os.Stdout).Encode(absences)
-			}
-
-			printAbsences(absences)
-			return nil
+			return absencesOpts.printer.Print(output.View{
+				Data: absences,
+				Table: func(t *output.Table) {
+					absencesTable(t, absences)
+				},
+			})
 		},
 	}
 )
 
 func init() {
-	absencesCmd.Flags().StringVarP(&absencesOpts.format, "format", "o", "table",
-		"Output format (table, json) note that other flags do not apply on json format")
+	absencesOpts.printer.AddFlags(absencesCmd.Flags(), output.TableFormat)
 	absencesCmd.Flags().UintVarP(&absencesOpts.year, "year", "y", currentAcademicYear(),
 		"Academic year (year at the start of the academic year, e.g. 2020 for 2020-2021 academic year)")
 	absencesCmd.Flags().StringVarP((*string)(&absencesOpts.semester), "semester", "s", string(ALL),
@@ -69,9 +70,8 @@ func init() {
 	rootCmd.AddCommand(absencesCmd)
 }
 
-func printAbsences(absences *parser.AbsenceReport) {
-	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
+// absencesTable lists the selected courses, the --semester and --rate flags don't apply to the other formats.
+func absencesTable(t *output.Table, absences *parser.AbsenceReport) {
 	t.Style().Options.SeparateRows = true
 	t.SetColumnConfigs([]table.ColumnConfig{
 		{Number: 1, AlignHeader: text.AlignCenter},
@@ -106,7 +106,6 @@ func printAbsences(absences *parser.AbsenceReport) {
 			})
 		}
 	}
-	t.Render()
 }
 
 func calculateAbsences(a *parser.CourseAbsence) (bool, float64, float64) {
@@ -136,9 +135,7 @@ func calculateAbsences(a *parser.CourseAbsence) (bool, float64, float64) {
 		absolutePresence = float64(a.Periods.Term3+a.Periods.Term4-a.Justified) / float64(a.AbsolutePeriods)
 
 	default:
-		selected = true
-		relativePresence = float64(a.Total-a.Justified) / float64(a.RelativePeriods)
-		absolutePresence = float64(a.Total-a.Justified) / float64(a.AbsolutePeriods)
+		return true, a.RelativeRate(), a.AbsoluteRate()
 	}
 	return selected, relativePresence * 100.0, absolutePresence * 100.0
 }
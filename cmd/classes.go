@@ -1,25 +1,45 @@
 package cmd
 
 import (
-	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/output"
 )
 
+type ClassesCmdOpts struct {
+	printer output.Printer
+}
+
 var (
-	classesCmd = &cobra.Command{
+	classesOpts = &ClassesCmdOpts{}
+	classesCmd  = &cobra.Command{
 		Use:   "classes",
 		Short: "Print the current class list",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			log.Debug("fetching classes")
-			cfg := buildTokenClientConfiguration()
-			classes := gaps.GetAllClasses(cfg, currentAcademicYear())
-			fmt.Println("Classes:", classes)
+			cfg, err := buildTokenClientConfiguration(cmd.Context(), rememberToken)
+			if err != nil {
+				return err
+			}
+			classes := gaps.GetAllClassesContext(cmd.Context(), cfg, currentAcademicYear())
+
+			return classesOpts.printer.Print(output.View{
+				Data: classes,
+				Table: func(t *output.Table) {
+					t.AppendHeader(table.Row{"Class"})
+					for _, class := range classes {
+						t.AppendRow(table.Row{class})
+					}
+				},
+			})
 		},
 	}
 )
 
 func init() {
+	classesOpts.printer.AddFlags(classesCmd.Flags(), output.TableFormat)
+
 	rootCmd.AddCommand(classesCmd)
 }
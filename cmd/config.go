@@ -0,0 +1,481 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+	"lutonite.dev/gaps-cli/output"
+)
+
+// configKind tells how the value of a key is parsed and validated.
+type configKind int
+
+const (
+	stringKind configKind = iota
+	boolKind
+	intKind
+	floatKind
+	intListKind
+	urlKind
+	fileKind
+	addressKind
+	// structuredKind values are lists of objects, edited in the configuration file only
+	structuredKind
+)
+
+type configKey struct {
+	kind configKind
+	// secret values are masked, the secretKeys are moreover kept in the credential store
+	secret bool
+	// readOnly keys are managed by other commands, hint tells which one
+	readOnly bool
+	hint     string
+	// min and max bound numeric values, max is ignored when lower than min
+	min float64
+	max float64
+}
+
+// configKeys describes the keys that aren't plain strings.
+var configKeys = map[ViperKey]configKey{
+	UrlViperKey:                       {kind: urlKind},
+	HistoryStoreFileViperKey:          {kind: fileKind},
+	GradesHistoryFileViperKey:         {kind: fileKind},
+	AbsencesHistoryFileViperKey:       {kind: fileKind},
+	ReportCardHistoryFileViperKey:     {kind: fileKind},
+	ScheduleHistoryFileViperKey:       {kind: fileKind},
+	PasswordViperKey:                  {secret: true},
+	ScraperApiUrlViperKey:             {kind: urlKind},
+	ScraperApiKeyViperKey:             {secret: true},
+	ScraperNotifiersViperKey:          {kind: structuredKind},
+	ScraperAccountsViperKey:           {kind: structuredKind},
+	ScraperRateLimitViperKey:          {kind: floatKind, min: 0, max: -1},
+	ScraperMetricsListenViperKey:      {kind: addressKind},
+	ScraperAbsencesViperKey:           {kind: boolKind},
+	ScraperReportCardViperKey:         {kind: boolKind},
+	ScraperScheduleViperKey:           {kind: boolKind},
+	ScraperScheduleLookaheadViperKey:  {kind: intKind, min: 0, max: 366},
+	AbsenceRelativeThresholdsViperKey: {kind: intListKind, min: 1, max: 100},
+	AbsenceAbsoluteThresholdsViperKey: {kind: intListKind, min: 1, max: 100},
+	TokenValueViperKey:                {secret: true, readOnly: true, hint: "gaps-cli login"},
+	TokenStudentIdViperKey:            {kind: intKind, readOnly: true, hint: "gaps-cli login"},
+	TokenDateValueViperKey:            {kind: intKind, readOnly: true, hint: "gaps-cli login"},
+	ServeIcalTokenViperKey:            {secret: true},
+	CredentialsBackendViperKey:        {readOnly: true, hint: "gaps-cli credentials migrate"},
+	CredentialsFileViperKey:           {kind: fileKind},
+}
+
+const secretMask = "********"
+
+type ConfigCmdOpts struct {
+	listPrinter output.Printer
+	getPrinter  output.Printer
+	showOrigin  bool
+	reveal      bool
+}
+
+// configEntry is the effective value of a key.
+type configEntry struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Origin string `json:"origin,omitempty"`
+}
+
+var (
+	configOpts = &ConfigCmdOpts{}
+	configCmd  = &cobra.Command{
+		Use:   "config",
+		Short: "Inspects and edits the settings of the profile",
+		// the configuration is only written by set and unset, keeping the file as edited
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {},
+	}
+	configListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "Lists the effective value of every setting",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries := make([]*configEntry, 0, len(viperKeys))
+			for _, key := range sortedViperKeys() {
+				entries = append(entries, newConfigEntry(cmd, key))
+			}
+
+			return configOpts.listPrinter.Print(output.View{
+				Data: entries,
+				Table: func(t *output.Table) {
+					configTable(t, entries...)
+				},
+			})
+		},
+	}
+	configGetCmd = &cobra.Command{
+		Use:   "get <key>",
+		Short: "Prints the effective value of a setting",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := lookupViperKey(args[0])
+			if err != nil {
+				return err
+			}
+
+			e := newConfigEntry(cmd, key)
+			return configOpts.getPrinter.Print(output.View{
+				Data: e,
+				Table: func(t *output.Table) {
+					configTable(t, e)
+				},
+				Text: func(w io.Writer) error {
+					var err error
+					if configOpts.showOrigin {
+						_, err = fmt.Fprintf(w, "%s\t%s\n", formatConfigValue(e.Value), e.Origin)
+					} else {
+						_, err = fmt.Fprintln(w, formatConfigValue(e.Value))
+					}
+					return err
+				},
+			})
+		},
+	}
+	configSetCmd = &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Stores a setting in the configuration file, or in the credential store for secrets",
+		Example: `  gaps-cli config set scraper.api.url https://notify.example.com
+  gaps-cli config set scraper.absences.thresholds.relative 10,20`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := lookupViperKey(args[0])
+			if err != nil {
+				return err
+			}
+
+			meta := configKeys[key]
+			if err := checkWritable(key, meta); err != nil {
+				return err
+			}
+
+			value, err := parseConfigValue(meta, args[1])
+			if err != nil {
+				return fmt.Errorf("invalid value for %s: %w", key, err)
+			}
+
+			if configViper(key) == credentialsViper {
+				return secretStore.Set(key.Key(), args[1])
+			}
+
+			return editConfigFile(func(settings map[string]any) {
+				setNested(settings, strings.Split(strings.ToLower(key.Key()), "."), value)
+			})
+		},
+	}
+	configUnsetCmd = &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Removes a setting, so that its default value applies again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := lookupViperKey(args[0])
+			if err != nil {
+				return err
+			}
+
+			meta := configKeys[key]
+			if err := checkWritable(key, meta); err != nil {
+				return err
+			}
+
+			if configViper(key) == credentialsViper {
+				return secretStore.Delete(key.Key())
+			}
+
+			return editConfigFile(func(settings map[string]any) {
+				deleteNested(settings, strings.Split(strings.ToLower(key.Key()), "."))
+			})
+		},
+	}
+)
+
+func init() {
+	configOpts.listPrinter.AddFlags(configListCmd.Flags(), output.TableFormat)
+	configListCmd.Flags().BoolVar(&configOpts.showOrigin, "show-origin", false, "Show where each value comes from")
+	configOpts.getPrinter.AddFlags(configGetCmd.Flags(), output.TextFormat)
+	configGetCmd.Flags().BoolVar(&configOpts.showOrigin, "show-origin", false, "Show where the value comes from")
+	configGetCmd.Flags().BoolVar(&configOpts.reveal, "reveal", false, "Print secrets instead of masking them")
+
+	configCmd.AddCommand(configListCmd, configGetCmd, configSetCmd, configUnsetCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func sortedViperKeys() []ViperKey {
+	keys := append([]ViperKey(nil), viperKeys...)
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+
+	return keys
+}
+
+func lookupViperKey(name string) (ViperKey, error) {
+	for _, key := range viperKeys {
+		if strings.EqualFold(key.Key(), name) {
+			return key, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown setting %q, see 'gaps-cli config list'", name)
+}
+
+func checkWritable(key ViperKey, meta configKey) error {
+	switch {
+	case meta.readOnly:
+		return fmt.Errorf("%s is managed by '%s'", key, meta.hint)
+	case meta.kind == structuredKind:
+		return fmt.Errorf("%s is a list of objects, edit it in %s", key, defaultViper.ConfigFileUsed())
+	case configViper(key) == credentialsViper && secretStore == nil:
+		return errors.New("no credential store available")
+	}
+
+	return nil
+}
+
+// configViper returns the viper holding a key.
+func configViper(key ViperKey) *viper.Viper {
+	for _, secret := range secretKeys {
+		if key == secret {
+			return credentialsViper
+		}
+	}
+
+	return defaultViper
+}
+
+func newConfigEntry(cmd *cobra.Command, key ViperKey) *configEntry {
+	meta := configKeys[key]
+	e := &configEntry{Key: key.Key(), Value: configViper(key).Get(key.Key()), Origin: configOrigin(cmd, key)}
+	if meta.secret && !configOpts.reveal && fmt.Sprint(e.Value) != "" {
+		e.Value = secretMask
+	}
+	if meta.kind == structuredKind {
+		e.Value = maskSecrets(e.Value)
+	}
+
+	return e
+}
+
+// configOrigin tells where the effective value of a key comes from, following the precedence of viper.
+func configOrigin(cmd *cobra.Command, key ViperKey) string {
+	if flag := cmd.Flags().Lookup(key.Flag()); key.Flag() != "" && flag != nil && flag.Changed && !boundFlags[flag.Name] {
+		return "flag --" + flag.Name
+	}
+
+	env := envPrefix + "_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key.Key()))
+	if _, ok := os.LookupEnv(env); ok {
+		return "env " + env
+	}
+
+	v := configViper(key)
+	switch {
+	case v == credentialsViper && storedSecrets[key] != "":
+		return fmt.Sprintf("credentials (%s)", secretStore.Backend())
+	case v.InConfig(key.Key()):
+		return "file " + v.ConfigFileUsed()
+	case v.Get(key.Key()) != nil:
+		return "default"
+	default:
+		return "unset"
+	}
+}
+
+func configTable(t *output.Table, entries ...*configEntry) {
+	header := table.Row{"Key", "Value"}
+	if configOpts.showOrigin {
+		header = append(header, "Origin")
+	}
+	t.AppendHeader(header)
+
+	for _, e := range entries {
+		row := table.Row{e.Key, formatConfigValue(e.Value)}
+		if configOpts.showOrigin {
+			row = append(row, e.Origin)
+		}
+		t.AppendRow(row)
+	}
+}
+
+func formatConfigValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []any, []map[string]any, map[string]any:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// maskSecrets hides the passwords and tokens of structured values, such as the accounts of the scraper.
+func maskSecrets(value any) any {
+	switch v := value.(type) {
+	case []any:
+		masked := make([]any, len(v))
+		for i, item := range v {
+			masked[i] = maskSecrets(item)
+		}
+		return masked
+	case map[string]any:
+		masked := make(map[string]any, len(v))
+		for key, item := range v {
+			lower := strings.ToLower(key)
+			if s, ok := item.(string); ok && s != "" &&
+				(strings.Contains(lower, "password") || strings.Contains(lower, "token") || strings.Contains(lower, "secret")) {
+				masked[key] = secretMask
+				continue
+			}
+			masked[key] = maskSecrets(item)
+		}
+		return masked
+	default:
+		return value
+	}
+}
+
+// parseConfigValue validates a value and converts it to the type stored in the configuration file.
+func parseConfigValue(meta configKey, raw string) (any, error) {
+	switch meta.kind {
+	case boolKind:
+		return strconv.ParseBool(raw)
+	case intKind:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, err
+		}
+		return n, checkRange(meta, float64(n))
+	case floatKind:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, err
+		}
+		return f, checkRange(meta, f)
+	case intListKind:
+		var list []int
+		for _, part := range strings.Split(raw, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			if err := checkRange(meta, float64(n)); err != nil {
+				return nil, err
+			}
+			list = append(list, n)
+		}
+		return list, nil
+	case urlKind:
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, errors.New("expected an http or https URL")
+		}
+		return strings.TrimSuffix(raw, "/"), nil
+	case fileKind:
+		path, err := filepath.Abs(raw)
+		if err != nil {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return nil, errors.New("expected a file, got a directory")
+		}
+		if info, err := os.Stat(filepath.Dir(path)); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("directory %s doesn't exist", filepath.Dir(path))
+		}
+		return path, nil
+	case addressKind:
+		if raw == "" {
+			return raw, nil
+		}
+		if _, _, err := net.SplitHostPort(raw); err != nil {
+			return nil, err
+		}
+		return raw, nil
+	default:
+		return raw, nil
+	}
+}
+
+func checkRange(meta configKey, value float64) error {
+	if value < meta.min || (meta.max >= meta.min && value > meta.max) {
+		if meta.max < meta.min {
+			return fmt.Errorf("must be at least %v", meta.min)
+		}
+		return fmt.Errorf("must be between %v and %v", meta.min, meta.max)
+	}
+
+	return nil
+}
+
+// editConfigFile applies fn to the settings of the configuration file only, so that neither defaults nor
+// environment variables end up written to it.
+func editConfigFile(fn func(settings map[string]any)) error {
+	path := defaultViper.ConfigFileUsed()
+	settings := make(map[string]any)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return err
+	}
+	if settings == nil {
+		settings = make(map[string]any)
+	}
+
+	fn(settings)
+
+	data, err = yaml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
+
+func setNested(settings map[string]any, parts []string, value any) {
+	for _, part := range parts[:len(parts)-1] {
+		nested, ok := settings[part].(map[string]any)
+		if !ok {
+			nested = make(map[string]any)
+			settings[part] = nested
+		}
+		settings = nested
+	}
+
+	settings[parts[len(parts)-1]] = value
+}
+
+func deleteNested(settings map[string]any, parts []string) {
+	if len(parts) == 1 {
+		delete(settings, parts[0])
+		return
+	}
+
+	nested, ok := settings[parts[0]].(map[string]any)
+	if !ok {
+		return
+	}
+
+	deleteNested(nested, parts[1:])
+	if len(nested) == 0 {
+		delete(settings, parts[0])
+	}
+}
@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"lutonite.dev/gaps-cli/credentials"
+	"lutonite.dev/gaps-cli/output"
+	"lutonite.dev/gaps-cli/util"
+)
+
+const (
+	// noCredentialsAnnotation marks the commands that never use the secrets, so they don't unlock the store
+	noCredentialsAnnotation = "gaps-cli/no-credentials"
+
+	passphraseEnv = envPrefix + "_CREDENTIALS_PASSPHRASE"
+)
+
+// secretKeys are the settings kept in the credential store instead of the configuration file.
+var secretKeys = []ViperKey{PasswordViperKey, TokenValueViperKey, ServeIcalTokenViperKey}
+
+// credentialsStatus describes the credential store in 'gaps-cli credentials status'.
+type credentialsStatus struct {
+	Backend credentials.Backend `json:"backend"`
+	File    string              `json:"file,omitempty"`
+	Stored  map[string]bool     `json:"stored"`
+}
+
+var (
+	credentialsStatusPrinter output.Printer
+
+	secretStore credentials.Store
+	// storedSecrets are the values of the secret keys in secretStore, to only write the changed ones
+	storedSecrets = make(map[ViperKey]string)
+
+	credentialsCmd = &cobra.Command{
+		Use:   "credentials",
+		Short: "Manages where the password and session token are stored",
+	}
+	credentialsStatusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "Shows the credential store in use and the secrets it holds",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status := &credentialsStatus{
+				Backend: secretStore.Backend(),
+				File:    credentialStorePath(secretStore.Backend()),
+				Stored:  make(map[string]bool),
+			}
+			for _, key := range secretKeys {
+				status.Stored[key.Key()] = storedSecrets[key] != ""
+			}
+			accountKeys := accountPasswordKeys(defaultViper)
+			for _, key := range accountKeys {
+				_, err := secretStore.Get(key)
+				status.Stored[key] = err == nil
+			}
+
+			return credentialsStatusPrinter.Print(output.View{
+				Data: status,
+				Table: func(t *output.Table) {
+					title := fmt.Sprintf("Backend: %s", status.Backend)
+					if status.File != "" {
+						title += fmt.Sprintf("\nFile: %s", status.File)
+					}
+					t.SetTitle(title)
+
+					t.AppendHeader(table.Row{"Secret", "Stored"})
+					keys := make([]string, 0, len(secretKeys)+len(accountKeys))
+					for _, key := range secretKeys {
+						keys = append(keys, key.Key())
+					}
+					for _, key := range append(keys, accountKeys...) {
+						stored := "no"
+						if status.Stored[key] {
+							stored = "yes"
+						}
+						t.AppendRow(table.Row{key, stored})
+					}
+				},
+			})
+		},
+	}
+	credentialsMigrateCmd = &cobra.Command{
+		Use:       "migrate keyring|file|plaintext",
+		Short:     "Moves the stored secrets to another backend and uses it from now on",
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{string(credentials.KeyringBackend), string(credentials.FileBackend), string(credentials.PlaintextBackend)},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			backend, err := credentials.ParseBackend(args[0])
+			if err != nil {
+				return err
+			}
+			if backend == secretStore.Backend() {
+				return fmt.Errorf("credentials are already stored in the %s backend", backend)
+			}
+
+			target, err := newCredentialStore(backend)
+			if err != nil {
+				return err
+			}
+
+			secrets := make(map[string]string)
+			for _, key := range secretKeys {
+				secrets[key.Key()] = storedSecrets[key]
+			}
+			for _, key := range accountPasswordKeys(defaultViper) {
+				value, err := secretStore.Get(key)
+				if err != nil && !errors.Is(err, credentials.ErrNotFound) {
+					return fmt.Errorf("could not read %s: %w", key, err)
+				}
+				secrets[key] = value
+			}
+
+			for key, value := range secrets {
+				if value != "" {
+					if err := target.Set(key, value); err != nil {
+						return fmt.Errorf("could not store %s: %w", key, err)
+					}
+				}
+			}
+
+			// only remove the secrets from the previous backend once they are all safely stored
+			for key := range secrets {
+				if err := secretStore.Delete(key); err != nil {
+					log.WithError(err).Warnf("Could not remove %s from the %s backend", key, secretStore.Backend())
+				}
+			}
+
+			secretStore = target
+			defaultViper.Set(CredentialsBackendViperKey.Key(), string(backend))
+			fmt.Printf("Credentials moved to the %s backend\n", backend)
+			return nil
+		},
+	}
+)
+
+func init() {
+	credentialsStatusPrinter.AddFlags(credentialsStatusCmd.Flags(), output.TableFormat)
+
+	credentialsCmd.AddCommand(credentialsStatusCmd, credentialsMigrateCmd)
+	rootCmd.AddCommand(credentialsCmd)
+}
+
+// initCredentials loads the secrets from the credential store, environment variables and flags still take
+// precedence over them.
+func initCredentials(cmd *cobra.Command) {
+	for c := cmd; c != nil; c = c.Parent() {
+		if _, ok := c.Annotations[noCredentialsAnnotation]; ok {
+			initEnv(cmd, credentialsViper)
+			return
+		}
+	}
+
+	store, err := openCredentialStore()
+	util.CheckErr(err)
+	secretStore = store
+	log.Debugf("using the %s credential store", store.Backend())
+
+	for _, key := range secretKeys {
+		value, err := store.Get(key.Key())
+		if errors.Is(err, credentials.ErrNotFound) {
+			continue
+		}
+		util.CheckErr(err)
+
+		credentialsViper.SetDefault(key.Key(), value)
+		storedSecrets[key] = value
+	}
+
+	initEnv(cmd, credentialsViper)
+}
+
+// writeCredentials saves the secrets that changed since they were loaded, remembering the backend chosen
+// automatically so the secrets are found again even if e.g. a keyring becomes available later on.
+func writeCredentials() {
+	if secretStore == nil {
+		return
+	}
+
+	for _, key := range secretKeys {
+		value := credentialsViper.GetString(key.Key())
+		if value == storedSecrets[key] {
+			continue
+		}
+
+		var err error
+		if value == "" {
+			err = secretStore.Delete(key.Key())
+		} else {
+			err = secretStore.Set(key.Key(), value)
+		}
+		if err != nil {
+			log.WithError(err).Errorf("Could not save %s to the %s credential store", key, secretStore.Backend())
+			continue
+		}
+
+		storedSecrets[key] = value
+		if value != "" {
+			rememberCredentialBackend()
+		}
+	}
+}
+
+// rememberCredentialBackend pins the backend chosen automatically, only once a secret was written to it so that
+// a failed write doesn't hide the secrets of the previously used store.
+func rememberCredentialBackend() {
+	if defaultViper.GetString(CredentialsBackendViperKey.Key()) == "" && secretStore.Backend() != credentials.PlaintextBackend {
+		defaultViper.Set(CredentialsBackendViperKey.Key(), string(secretStore.Backend()))
+	}
+}
+
+// openCredentialStore returns the configured credential store. Unless configured otherwise, the secrets stored in
+// plaintext by previous versions keep being used until they are migrated, then the OS keyring is preferred over
+// an encrypted file.
+func openCredentialStore() (credentials.Store, error) {
+	name := defaultViper.GetString(CredentialsBackendViperKey.Key())
+	if name != "" && name != "auto" {
+		backend, err := credentials.ParseBackend(name)
+		if err != nil {
+			return nil, err
+		}
+
+		return newCredentialStore(backend)
+	}
+
+	plaintext := credentials.NewPlaintext(credentialStorePath(credentials.PlaintextBackend))
+	for _, key := range secretKeys {
+		if _, err := plaintext.Get(key.Key()); err == nil {
+			log.Warn("Credentials are stored in plaintext, use 'gaps-cli credentials migrate keyring' or 'gaps-cli credentials migrate file' to protect them")
+			return plaintext, nil
+		}
+	}
+
+	if _, err := os.Stat(credentialStorePath(credentials.FileBackend)); err == nil {
+		return newCredentialStore(credentials.FileBackend)
+	}
+
+	if keyring := credentials.NewKeyring(keyringService(activeProfile)); keyring.Available() {
+		return keyring, nil
+	}
+
+	return newCredentialStore(credentials.FileBackend)
+}
+
+func newCredentialStore(backend credentials.Backend) (credentials.Store, error) {
+	switch backend {
+	case credentials.KeyringBackend:
+		keyring := credentials.NewKeyring(keyringService(activeProfile))
+		if !keyring.Available() {
+			return nil, credentials.ErrKeyringUnusable
+		}
+		return keyring, nil
+	case credentials.FileBackend:
+		return credentials.NewEncryptedFile(credentialStorePath(backend), readPassphrase), nil
+	default:
+		return credentials.NewPlaintext(credentialStorePath(backend)), nil
+	}
+}
+
+// credentialStorePath returns the file of the file based backends.
+func credentialStorePath(backend credentials.Backend) string {
+	switch backend {
+	case credentials.FileBackend:
+		if path := defaultViper.GetString(CredentialsFileViperKey.Key()); path != "" {
+			return path
+		}
+		return getProfileDirectory() + "/credentials.enc"
+	case credentials.PlaintextBackend:
+		if credsFile != "" {
+			return credsFile
+		}
+		return getProfileDirectory() + "/credentials.yaml"
+	default:
+		return ""
+	}
+}
+
+// keyringService separates the secrets of each profile in the OS keyring.
+func keyringService(profile string) string {
+	if profile == defaultProfile {
+		return "gaps-cli"
+	}
+
+	return "gaps-cli/" + profile
+}
+
+// readPassphrase reads the passphrase of the encrypted credentials file from the environment, or asks for it.
+func readPassphrase(create bool) (string, error) {
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("the credentials file is encrypted, set its passphrase in %s", passphraseEnv)
+	}
+
+	if !create {
+		return promptPassword("Enter the passphrase of the credentials file: ")
+	}
+
+	passphrase, err := promptPassword("Choose a passphrase for the credentials file: ")
+	if err != nil {
+		return "", err
+	}
+
+	confirmation, err := promptPassword("Enter the passphrase again: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != confirmation {
+		return "", errors.New("passphrases don't match")
+	}
+
+	return passphrase, nil
+}
+
+func promptPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	return string(password), err
+}
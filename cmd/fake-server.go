@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"lutonite.dev/gaps-cli/gaps/gapstest"
+)
+
+type FakeServerCmdOpts struct {
+	listen   string
+	fixtures string
+	dump     bool
+}
+
+var (
+	fakeServerOpts = &FakeServerCmdOpts{}
+	fakeServerCmd  = &cobra.Command{
+		Use:         "fake-server",
+		Short:       "Runs a fake GAPS server serving fixture data, for offline testing with --url",
+		Annotations: map[string]string{noCredentialsAnnotation: ""},
+		Hidden:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fixtures := gapstest.DefaultFixtures()
+			if fakeServerOpts.fixtures != "" {
+				var err error
+				if fixtures, err = gapstest.LoadFixtures(fakeServerOpts.fixtures); err != nil {
+					return err
+				}
+			}
+
+			if fakeServerOpts.dump {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "\t")
+				return enc.Encode(fixtures)
+			}
+
+			srv := &http.Server{
+				Addr:    fakeServerOpts.listen,
+				Handler: gapstest.NewGaps(fixtures),
+			}
+
+			go func() {
+				<-cmd.Context().Done()
+				srv.Close()
+			}()
+
+			log.Infof("Serving fake GAPS on http://%s", fakeServerOpts.listen)
+			if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+
+			return nil
+		},
+	}
+)
+
+func init() {
+	fakeServerCmd.Flags().StringVar(&fakeServerOpts.listen, "listen", "127.0.0.1:8081", "Address to listen on")
+	fakeServerCmd.Flags().StringVar(&fakeServerOpts.fixtures, "fixtures", "", "JSON fixtures file (default is the built-in data set)")
+	fakeServerCmd.Flags().BoolVar(&fakeServerOpts.dump, "dump", false, "Print the fixtures as JSON instead of serving them")
+
+	rootCmd.AddCommand(fakeServerCmd)
+}
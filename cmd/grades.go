@@ -1,7 +1,8 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
@@ -9,16 +10,16 @@ import (
 	"github.com/spf13/cobra"
 	ch "lutonite.dev/gaps-cli/cal"
 	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/output"
 	"lutonite.dev/gaps-cli/parser"
 	"lutonite.dev/gaps-cli/util"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 )
 
 type GradesCmdOpts struct {
-	format   string
+	printer  output.Printer
 	year     string
 	class    string
 	semester gaps.Semester
@@ -33,17 +34,24 @@ var (
 		Use:   "grades",
 		Short: "Allows to consult your grades",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg := buildTokenClientConfiguration()
+			cfg, err := buildTokenClientConfiguration(cmd.Context(), rememberToken)
+			if err != nil {
+				return err
+			}
 
 			var classGrades []*parser.ClassGrades
+			// sources holds the academic year and semester of each class, for the records
+			var sources []gradesSource
 			for _, sYear := range strings.Split(gradesOpts.year, ",") {
 				year, err := strconv.ParseUint(sYear, 10, 32)
 				util.CheckErr(err)
-				grades := gaps.NewSemesterGradesAction(cfg, uint(year), gradesOpts.semester)
-				grades.ClassFilter = gradesOpts.class
-				res, err := grades.FetchGrades()
+
+				res, semesters, err := gradesOpts.fetchYear(cmd.Context(), cfg, uint(year))
 				util.CheckErr(err)
 				classGrades = append(classGrades, res...)
+				for _, semester := range semesters {
+					sources = append(sources, gradesSource{year: uint(year), semester: semester})
+				}
 			}
 
 			if len(classGrades) == 0 {
@@ -51,18 +59,21 @@ var (
 				return nil
 			}
 
-			if gradesOpts.format == "json" {
-				return json.NewEncoder(os.Stdout).Encode(classGrades)
-			}
-
-			gradesOpts.PrintGradesTable(classGrades)
-			return nil
+			return gradesOpts.printer.Print(output.View{
+				Data: classGrades,
+				Table: func(t *output.Table) {
+					gradesOpts.BuildGradesTable(t, classGrades)
+				},
+				Records: func(t *output.Table) {
+					gradesOpts.BuildGradesRecords(t, classGrades, sources)
+				},
+			})
 		},
 	}
 )
 
 func init() {
-	gradesCmd.Flags().StringVarP(&gradesOpts.format, "format", "o", "table", "Output format (table, json)")
+	gradesOpts.printer.AddFlags(gradesCmd.Flags(), output.TableFormat)
 	gradesCmd.Flags().StringVar(&gradesOpts.class, "class", "", "Get grades for specific class")
 	gradesCmd.Flags().StringVarP(
 		&gradesOpts.year, "year", "y", gradesOpts.defaultYear(),
@@ -92,13 +103,75 @@ func currentSemester() gaps.Semester {
 	return gaps.Second
 }
 
+// gradesSource is where a class of the grades comes from.
+type gradesSource struct {
+	year     uint
+	semester gaps.Semester
+}
+
+// fetchYear fetches the grades of a year along with the semester of each class. GAPS doesn't tell the semester of
+// the classes it lists for the whole year, so the semesters are only looked up for the records, which have a column
+// for it.
+func (g *GradesCmdOpts) fetchYear(ctx context.Context, cfg *gaps.TokenClientConfiguration, year uint) ([]*parser.ClassGrades, []gaps.Semester, error) {
+	grades := gaps.NewSemesterGradesAction(cfg, year, g.semester)
+	grades.ClassFilter = g.class
+	classGrades, err := grades.FetchGradesContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	semesters := make([]gaps.Semester, len(classGrades))
+	for i := range semesters {
+		semesters[i] = g.semester
+	}
+	if g.semester != gaps.All || !g.printer.PrintsRecords() {
+		return classGrades, semesters, nil
+	}
+
+	classSemesters, err := g.classSemesters(ctx, cfg, year)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, class := range classGrades {
+		if semester, ok := classSemesters[class.Name]; ok {
+			semesters[i] = semester
+		}
+	}
+
+	return classGrades, semesters, nil
+}
+
+// classSemesters fetches both semesters one after the other to tell the semester of each class, a class listed in
+// both spanning the whole year.
+func (g *GradesCmdOpts) classSemesters(ctx context.Context, cfg *gaps.TokenClientConfiguration, year uint) (map[string]gaps.Semester, error) {
+	semesters := make(map[string]gaps.Semester)
+	for _, semester := range []gaps.Semester{gaps.First, gaps.Second} {
+		grades := gaps.NewSemesterGradesAction(cfg, year, semester)
+		grades.ClassFilter = g.class
+		res, err := grades.FetchGradesContext(ctx)
+		if errors.Is(err, gaps.ErrClassNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, class := range res {
+			if _, ok := semesters[class.Name]; ok {
+				semesters[class.Name] = gaps.All
+			} else {
+				semesters[class.Name] = semester
+			}
+		}
+	}
+
+	return semesters, nil
+}
+
 func (*GradesCmdOpts) defaultYear() string {
 	return fmt.Sprintf("%d", currentAcademicYear())
 }
 
-func (g *GradesCmdOpts) PrintGradesTable(classGrades []*parser.ClassGrades) {
-	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
+func (g *GradesCmdOpts) BuildGradesTable(t *output.Table, classGrades []*parser.ClassGrades) {
 	t.Style().Options.SeparateRows = true
 	t.SetColumnConfigs([]table.ColumnConfig{
 		{Number: 1, AutoMerge: true},
@@ -144,6 +217,41 @@ func (g *GradesCmdOpts) PrintGradesTable(classGrades []*parser.ClassGrades) {
 
 		t.AppendSeparator()
 	}
+}
+
+// BuildGradesRecords lists a row per grade for spreadsheets, the columns must keep their order for re-imports.
+func (g *GradesCmdOpts) BuildGradesRecords(t *output.Table, classGrades []*parser.ClassGrades, sources []gradesSource) {
+	t.Name = "Grades"
+	t.AppendHeader(table.Row{"year", "semester", "class", "group", "group_weight", "description", "date", "weight", "grade", "class_mean"})
+
+	for i, classGrade := range classGrades {
+		for _, group := range classGrade.GradeGroups {
+			for _, grade := range group.Grades {
+				t.AppendRow(table.Row{
+					sources[i].year,
+					sources[i].semester.String(),
+					classGrade.Name,
+					group.Name,
+					group.Weight,
+					grade.Description,
+					grade.Date.Format(scheduleDateFormat),
+					grade.Weight,
+					gradeCell(grade.Value, grade.Grade),
+					gradeCell(grade.ClassMeanValue, grade.ClassMean),
+				})
+			}
+		}
+	}
+}
+
+// gradeCell keeps grades numeric in spreadsheets, and values such as exemptions as GAPS shows them.
+func gradeCell(value parser.GradeValue, raw string) any {
+	if grade, ok := value.Float(); ok {
+		return grade
+	}
+	if value.IsExempt() {
+		return raw
+	}
 
-	t.Render()
+	return nil
 }
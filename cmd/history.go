@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"lutonite.dev/gaps-cli/output"
+	"lutonite.dev/gaps-cli/store"
+)
+
+type HistoryCmdOpts struct {
+	printer   output.Printer
+	storeFile string
+	class     string
+	from      string
+	to        string
+}
+
+// historyEntry is a change of a grade between two snapshots recorded by the scraper.
+type historyEntry struct {
+	Time              time.Time `json:"time"`
+	Change            string    `json:"change"`
+	Course            string    `json:"course"`
+	Type              string    `json:"type"`
+	Description       string    `json:"description"`
+	Grade             string    `json:"grade"`
+	PreviousGrade     string    `json:"previousGrade,omitempty"`
+	ClassMean         string    `json:"classMean"`
+	PreviousClassMean string    `json:"previousClassMean,omitempty"`
+}
+
+var (
+	historyOpts = &HistoryCmdOpts{}
+	historyCmd  = &cobra.Command{
+		Use:         "history",
+		Short:       "Shows when grades and class means appeared and changed, as recorded by the scraper",
+		Annotations: map[string]string{noCredentialsAnnotation: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, to, err := historyOpts.dateRange()
+			if err != nil {
+				return err
+			}
+
+			entries, err := readGradeTimeline(historyOpts.storeFile)
+			if err != nil {
+				return err
+			}
+
+			filtered := make([]*historyEntry, 0, len(entries))
+			for _, e := range entries {
+				if historyOpts.class != "" && !strings.EqualFold(e.Course, historyOpts.class) {
+					continue
+				}
+				if (!from.IsZero() && e.Time.Before(from)) || (!to.IsZero() && !e.Time.Before(to)) {
+					continue
+				}
+
+				filtered = append(filtered, e)
+			}
+
+			return historyOpts.printer.Print(output.View{
+				Data: filtered,
+				Table: func(t *output.Table) {
+					historyTable(t, filtered)
+				},
+				Records: func(t *output.Table) {
+					historyRecords(t, filtered)
+				},
+			})
+		},
+	}
+)
+
+func init() {
+	historyOpts.printer.AddFlags(historyCmd.Flags(), output.TableFormat)
+	historyCmd.Flags().StringVarP(&historyOpts.class, "class", "c", "", "Only show the grades of this class")
+	historyCmd.Flags().StringVar(&historyOpts.from, "from", "", "Only show changes since this day, YYYY-MM-DD")
+	historyCmd.Flags().StringVar(&historyOpts.to, "to", "", "Only show changes until this day included, YYYY-MM-DD")
+
+	historyCmd.Flags().StringVar(&historyOpts.storeFile, HistoryStoreFileViperKey.Flag(), "", "history database (default is history.db in the profile directory)")
+	defaultViper.BindPFlag(HistoryStoreFileViperKey.Key(), historyCmd.Flags().Lookup(HistoryStoreFileViperKey.Flag()))
+
+	rootCmd.AddCommand(historyCmd)
+}
+
+func (o *HistoryCmdOpts) dateRange() (from time.Time, to time.Time, err error) {
+	if o.from != "" {
+		if from, err = time.ParseInLocation(scheduleDateFormat, o.from, time.Local); err != nil {
+			return from, to, fmt.Errorf("invalid --from date: %w", err)
+		}
+	}
+
+	if o.to != "" {
+		if to, err = time.ParseInLocation(scheduleDateFormat, o.to, time.Local); err != nil {
+			return from, to, fmt.Errorf("invalid --to date: %w", err)
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+
+	return from, to, nil
+}
+
+// readGradeTimeline reads the grade timeline of the history database at path, which is empty when the scraper
+// hasn't created the database yet.
+func readGradeTimeline(path string) ([]*historyEntry, error) {
+	st, err := store.OpenReadOnly(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		log.WithField("file", path).Info("No history database, the scraper hasn't recorded anything yet")
+		return nil, nil
+	case errors.Is(err, store.ErrOutdatedSchema):
+		return nil, fmt.Errorf("the history database %s was written by an older version of gaps-cli, run 'gaps-cli scraper' once to upgrade it", path)
+	case err != nil:
+		return nil, err
+	}
+	defer st.Close()
+
+	return gradeTimeline(st)
+}
+
+// gradeTimeline replays the grades snapshots in order, producing an entry whenever a grade appears, changes or
+// disappears. As snapshots are only stored when something changed, the time of an entry is when the scraper
+// first saw the change.
+func gradeTimeline(st *store.Store) ([]*historyEntry, error) {
+	var entries []*historyEntry
+	var previous scraperResult
+
+	err := st.Snapshots(store.Grades, func(snapshot *store.Snapshot) error {
+		var current scraperResult
+		if err := snapshot.Decode(&current); err != nil {
+			return err
+		}
+
+		for _, course := range sortedKeys(current) {
+			for _, description := range sortedKeys(current[course]) {
+				grade := current[course][description]
+				before := previous[course][description]
+
+				entry := &historyEntry{
+					Time:        snapshot.Time,
+					Course:      course,
+					Type:        grade.Type,
+					Description: description,
+					Grade:       grade.Grade,
+					ClassMean:   grade.ClassMean,
+				}
+
+				switch {
+				case before == nil:
+					entry.Change = "new"
+				case before.Grade != grade.Grade || before.ClassMean != grade.ClassMean:
+					entry.Change = "updated"
+					entry.PreviousGrade = before.Grade
+					entry.PreviousClassMean = before.ClassMean
+				default:
+					continue
+				}
+
+				entries = append(entries, entry)
+			}
+		}
+
+		for _, course := range sortedKeys(previous) {
+			for _, description := range sortedKeys(previous[course]) {
+				if current[course][description] != nil {
+					continue
+				}
+
+				before := previous[course][description]
+				entries = append(entries, &historyEntry{
+					Time:              snapshot.Time,
+					Change:            "removed",
+					Course:            course,
+					Type:              before.Type,
+					Description:       description,
+					PreviousGrade:     before.Grade,
+					PreviousClassMean: before.ClassMean,
+				})
+			}
+		}
+
+		previous = current
+		return nil
+	})
+
+	return entries, err
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func historyTable(t *output.Table, entries []*historyEntry) {
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 2, Align: text.AlignCenter, AlignHeader: text.AlignCenter},
+		{Number: 5, Align: text.AlignCenter, AlignHeader: text.AlignCenter},
+		{Number: 6, Align: text.AlignCenter, AlignHeader: text.AlignCenter},
+	})
+
+	t.AppendHeader(table.Row{"Seen at", "Change", "Class", "Grade", "Value", "Class mean"})
+	for _, e := range entries {
+		t.AppendRow(table.Row{
+			e.Time.Local().Format("02.01.2006 15:04"),
+			e.Change,
+			e.Course,
+			fmt.Sprintf("%s (%s)", e.Description, e.Type),
+			historyTransition(e.PreviousGrade, e.Grade, e.Change),
+			historyTransition(e.PreviousClassMean, e.ClassMean, e.Change),
+		})
+	}
+}
+
+func historyTransition(previous string, current string, change string) string {
+	switch {
+	case change == "removed":
+		return previous
+	case change == "updated" && previous != current:
+		return fmt.Sprintf("%s -> %s", previous, current)
+	default:
+		return current
+	}
+}
+
+// historyRecords keeps the previous and current values apart, for spreadsheets.
+func historyRecords(t *output.Table, entries []*historyEntry) {
+	t.AppendHeader(table.Row{"time", "change", "course", "type", "description", "grade", "previous_grade", "class_mean", "previous_class_mean"})
+	for _, e := range entries {
+		t.AppendRow(table.Row{
+			e.Time.Format(time.RFC3339),
+			e.Change,
+			e.Course,
+			e.Type,
+			e.Description,
+			e.Grade,
+			e.PreviousGrade,
+			e.ClassMean,
+			e.PreviousClassMean,
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"lutonite.dev/gaps-cli/store"
+)
+
+func TestReadGradeTimeline(t *testing.T) {
+	path := t.TempDir() + "/history.db"
+
+	entries, err := readGradeTimeline(path)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("readGradeTimeline without database = %v, %v, want an empty timeline", entries, err)
+	}
+
+	st, err := store.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	snapshots := []scraperResult{
+		{"ARN": {"TE1": {Course: "ARN", Type: "Cours", Description: "TE1", Grade: "4.5", ClassMean: "-"}}},
+		{"ARN": {"TE1": {Course: "ARN", Type: "Cours", Description: "TE1", Grade: "4.5", ClassMean: "4.2"}}},
+		{},
+	}
+	for i, snapshot := range snapshots {
+		if _, err := st.Save(store.Grades, start.Add(time.Duration(i)*time.Hour), snapshot); err != nil {
+			t.Fatal(err)
+		}
+	}
+	st.Close()
+
+	entries, err = readGradeTimeline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var changes []string
+	for _, e := range entries {
+		changes = append(changes, e.Change)
+	}
+	if got := strings.Join(changes, ","); got != "new,updated,removed" {
+		t.Errorf("changes = %s, want new,updated,removed", got)
+	}
+}
+
+func TestReadGradeTimelineOutdatedSchema(t *testing.T) {
+	// a database without schema version, as written before the migrations
+	path := t.TempDir() + "/history.db"
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	_, err = readGradeTimeline(path)
+	if err == nil || !strings.Contains(err.Error(), "gaps-cli scraper") {
+		t.Errorf("readGradeTimeline = %v, want an error telling to run the scraper", err)
+	}
+}
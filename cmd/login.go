@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -22,14 +24,10 @@ var (
 		Use:   "login",
 		Short: "Allows to login to GAPS for future commands",
 		Run: func(cmd *cobra.Command, args []string) {
+			// an expired token is replaced transparently by the next command using it
 			if credentialsViper.GetString(TokenValueViperKey.Key()) != "" && !loginOpts.changePassword {
-				// Default session duration is 6 hours on GAPS
-				if !isTokenExpired() {
-					log.Info("User already logged in, keeping existing token")
-					return
-				}
-
-				log.Info("Token expired, attempting refresh")
+				log.Info("User already logged in, keeping existing token")
+				return
 			}
 
 			var username string
@@ -55,7 +53,11 @@ var (
 				password = credentialsViper.GetString(PasswordViperKey.Key())
 			}
 
-			refreshToken(username, password)
+			err := refreshToken(cmd.Context(), username, password)
+			if errors.Is(err, gaps.ErrInvalidCredentials) {
+				log.Fatal("Invalid credentials, use 'gaps-cli login --clear-password' to enter them again")
+			}
+			util.CheckErr(err)
 		},
 	}
 )
@@ -76,25 +78,25 @@ func init() {
 	rootCmd.AddCommand(loginCmd)
 }
 
-func isTokenExpired() bool {
-	return time.Now().UnixMilli()-defaultViper.GetInt64(TokenDateValueViperKey.Key()) > 6*60*60*1000
-}
-
-func refreshToken(username string, password string) {
+// refreshToken logs in to GAPS and saves the new session token along with the credentials.
+func refreshToken(ctx context.Context, username string, password string) error {
 	defaultViper.Set(UsernameViperKey.Key(), username)
 	credentialsViper.Set(PasswordViperKey.Key(), password)
 
-	cfg := new(gaps.ClientConfiguration)
-	cfg.Init(defaultViper.GetString(UrlViperKey.Key()))
+	cfg := newClientConfiguration()
 
 	log.Debug("fetching token...")
 	login := gaps.NewLoginAction(cfg, username, password)
-	token, err := login.FetchToken()
-	util.CheckErr(err)
+	token, err := login.FetchTokenContext(ctx)
+	if err != nil {
+		return err
+	}
 
 	log.Debug("fetching student id...")
-	studentId, err := login.FetchStudentId(token)
-	util.CheckErr(err)
+	studentId, err := login.FetchStudentIdContext(ctx, token)
+	if err != nil {
+		return err
+	}
 
 	log.Info("Successfully logged in")
 	log.Tracef("Token: %s", token)
@@ -106,25 +108,71 @@ func refreshToken(username string, password string) {
 
 	log.Debug("saving config")
 	writeConfig()
+	return nil
 }
 
-func buildTokenClientConfiguration() *gaps.TokenClientConfiguration {
-	if credentialsViper.GetString(TokenValueViperKey.Key()) == "" {
-		log.Fatal("No token found, please login first")
+// buildTokenClientConfiguration returns a client using the stored token, onTokenRefresh is called with the new
+// token whenever GAPS expired the session and the client logged in again.
+func buildTokenClientConfiguration(ctx context.Context, onTokenRefresh func(token string)) (*gaps.TokenClientConfiguration, error) {
+	// captures must be self-contained, so they always start with a login
+	if recordDir != "" || replayDir != "" {
+		err := refreshToken(ctx, defaultViper.GetString(UsernameViperKey.Key()), credentialsViper.GetString(PasswordViperKey.Key()))
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// if token is expired, refresh it
-	if isTokenExpired() {
-		log.Info("Token expired, attempting refresh")
-		refreshToken(defaultViper.GetString(UsernameViperKey.Key()), credentialsViper.GetString(PasswordViperKey.Key()))
+	if credentialsViper.GetString(TokenValueViperKey.Key()) == "" {
+		return nil, gaps.ErrNotLoggedIn
 	}
 
 	cfg := new(gaps.TokenClientConfiguration)
-	cfg.InitToken(
+	err := cfg.InitToken(
 		defaultViper.GetString(UrlViperKey.Key()),
 		credentialsViper.GetString(TokenValueViperKey.Key()),
 		defaultViper.GetUint(TokenStudentIdViperKey.Key()),
 	)
+	if err != nil {
+		return nil, err
+	}
+	if transport != nil {
+		cfg.SetTransport(transport)
+	}
+
+	// GAPS may kill the session at any time, log in again with the stored credentials when it does
+	cfg.SetCredentialProvider(gaps.CredentialProviderFunc(func(ctx context.Context) (string, string, error) {
+		username := defaultViper.GetString(UsernameViperKey.Key())
+		password := credentialsViper.GetString(PasswordViperKey.Key())
+		if username == "" || password == "" {
+			return "", "", gaps.ErrNotLoggedIn
+		}
 
-	return cfg
+		return username, password, nil
+	}))
+	cfg.OnTokenRefresh(onTokenRefresh)
+
+	return cfg, nil
+}
+
+// rememberToken keeps a token obtained by a transparent re-authentication, for the configuration to be written
+// when the command is done.
+func rememberToken(token string) {
+	credentialsViper.Set(TokenValueViperKey.Key(), token)
+	defaultViper.Set(TokenDateValueViperKey.Key(), time.Now().UnixMilli())
+	tokenPending = true
+}
+
+// writePendingToken writes a remembered token the command didn't get to write, cobra skipping PersistentPostRun
+// when a command fails.
+func writePendingToken() {
+	if tokenPending {
+		writeConfig()
+	}
+}
+
+// persistToken saves a token obtained by a transparent re-authentication right away, for the long-running
+// commands.
+func persistToken(token string) {
+	rememberToken(token)
+	writeConfig()
 }
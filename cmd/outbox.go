@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+	"lutonite.dev/gaps-cli/notifier"
+	"lutonite.dev/gaps-cli/output"
+	"lutonite.dev/gaps-cli/store"
+)
+
+type OutboxCmdOpts struct {
+	printer   output.Printer
+	storeFile string
+	dead      bool
+	all       bool
+}
+
+var (
+	outboxOpts = &OutboxCmdOpts{}
+	outboxCmd  = &cobra.Command{
+		Use:         "outbox",
+		Short:       "Inspects the notifications waiting to be delivered by the scraper",
+		Annotations: map[string]string{noCredentialsAnnotation: ""},
+	}
+	outboxListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "Lists the pending notifications, or the dead letters that failed too many times",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			st, err := store.OpenReadOnly(outboxOpts.storeFile)
+			if err != nil {
+				return err
+			}
+			defer st.Close()
+
+			deliveries := make([]*store.Delivery, 0)
+			if err := st.Deliveries(outboxOpts.dead, func(d *store.Delivery) error {
+				deliveries = append(deliveries, d)
+				return nil
+			}); err != nil {
+				return err
+			}
+
+			return outboxOpts.printer.Print(output.View{
+				Data: deliveries,
+				Table: func(t *output.Table) {
+					deliveriesTable(t, deliveries)
+				},
+			})
+		},
+	}
+	outboxReplayCmd = &cobra.Command{
+		Use:   "replay [id...]",
+		Short: "Moves dead letters back to the outbox, to be delivered on the next scraper run",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && !outboxOpts.all {
+				return errors.New("give the ids of the dead letters to replay, or --all")
+			}
+
+			ids, err := parseDeliveryIds(args)
+			if err != nil {
+				return err
+			}
+
+			st, err := store.Open(outboxOpts.storeFile)
+			if err != nil {
+				return err
+			}
+			defer st.Close()
+
+			replayed, err := st.Replay(ids...)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Replayed %d notifications, they will be delivered on the next scraper run\n", replayed)
+			return nil
+		},
+	}
+	outboxDropCmd = &cobra.Command{
+		Use:   "drop id...",
+		Short: "Deletes pending notifications, or dead letters with --dead",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseDeliveryIds(args)
+			if err != nil {
+				return err
+			}
+
+			st, err := store.Open(outboxOpts.storeFile)
+			if err != nil {
+				return err
+			}
+			defer st.Close()
+
+			return st.Drop(outboxOpts.dead, ids...)
+		},
+	}
+)
+
+func init() {
+	outboxCmd.PersistentFlags().StringVar(&outboxOpts.storeFile, HistoryStoreFileViperKey.Flag(), "", "history database (default is history.db in the profile directory)")
+	defaultViper.BindPFlag(HistoryStoreFileViperKey.Key(), outboxCmd.PersistentFlags().Lookup(HistoryStoreFileViperKey.Flag()))
+
+	outboxOpts.printer.AddFlags(outboxListCmd.Flags(), output.TableFormat)
+	outboxListCmd.Flags().BoolVar(&outboxOpts.dead, "dead", false, "List the dead letters instead of the pending notifications")
+	outboxReplayCmd.Flags().BoolVar(&outboxOpts.all, "all", false, "Replay every dead letter")
+	outboxDropCmd.Flags().BoolVar(&outboxOpts.dead, "dead", false, "Delete dead letters instead of pending notifications")
+
+	outboxCmd.AddCommand(outboxListCmd, outboxReplayCmd, outboxDropCmd)
+	rootCmd.AddCommand(outboxCmd)
+}
+
+func parseDeliveryIds(args []string) ([]uint64, error) {
+	ids := make([]uint64, len(args))
+	for i, arg := range args {
+		id, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid id %q", arg)
+		}
+		ids[i] = id
+	}
+
+	return ids, nil
+}
+
+func deliveriesTable(t *output.Table, deliveries []*store.Delivery) {
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 5, Align: text.AlignCenter, AlignHeader: text.AlignCenter},
+	})
+
+	t.AppendHeader(table.Row{"Id", "Notifier", "Event", "Detected at", "Attempts", "Next attempt", "Last error"})
+	for _, d := range deliveries {
+		next := "-"
+		if !d.Dead {
+			next = d.NextAttempt.Local().Format("02.01.2006 15:04")
+		}
+
+		t.AppendRow(table.Row{
+			d.Id,
+			d.Notifier,
+			describeEvent(d.Event),
+			d.Event.Time.Local().Format("02.01.2006 15:04"),
+			d.Attempts,
+			next,
+			d.LastError,
+		})
+	}
+}
+
+// describeEvent summarizes an event in a few words.
+func describeEvent(e *notifier.Event) string {
+	switch {
+	case e.Grade != nil:
+		return fmt.Sprintf("grade %s: %s", e.Grade.Course, e.Grade.Name)
+	case e.Absence != nil:
+		return fmt.Sprintf("absence %s: %s", e.Absence.Course, e.Absence.Reason)
+	case e.Module != nil:
+		return fmt.Sprintf("module %s: %s", e.Module.Module, e.Module.Reason)
+	case e.Lesson != nil:
+		return fmt.Sprintf("lesson %s: %s", e.Lesson.Summary, e.Lesson.Reason)
+	default:
+		return string(e.Type)
+	}
+}
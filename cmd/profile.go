@@ -0,0 +1,385 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+	"lutonite.dev/gaps-cli/credentials"
+	"lutonite.dev/gaps-cli/output"
+	"lutonite.dev/gaps-cli/util"
+)
+
+// defaultProfile lives directly in the configuration directory, as the single profile of previous versions did.
+const defaultProfile = "default"
+
+// activeProfileFile holds the name of the profile selected with 'gaps-cli profile use'.
+const activeProfileFile = "active-profile"
+
+var profileNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][\w-]*$`)
+
+type ProfileCmdOpts struct {
+	printer output.Printer
+	use     bool
+	yes     bool
+}
+
+// profileInfo describes a profile in 'gaps-cli profile list'.
+type profileInfo struct {
+	Name      string `json:"name"`
+	Active    bool   `json:"active"`
+	Url       string `json:"url"`
+	Username  string `json:"username"`
+	StudentId int    `json:"studentId,omitempty"`
+}
+
+var (
+	// profileName is the --profile flag, activeProfile the profile in use once resolved
+	profileName   string
+	activeProfile = defaultProfile
+
+	profileOpts = &ProfileCmdOpts{}
+	profileCmd  = &cobra.Command{
+		Use:         "profile",
+		Short:       "Manages profiles, each with its own GAPS instance, account and history",
+		Annotations: map[string]string{noCredentialsAnnotation: ""},
+	}
+	profileListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "Lists the profiles",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names, err := listProfiles()
+			if err != nil {
+				return err
+			}
+
+			selected := readActiveProfile()
+			profiles := make([]*profileInfo, 0, len(names))
+			for _, name := range names {
+				cfg := readProfileConfig(name)
+				info := &profileInfo{
+					Name:     name,
+					Active:   name == selected,
+					Url:      cfg.GetString(UrlViperKey.Key()),
+					Username: cfg.GetString(UsernameViperKey.Key()),
+				}
+				if id := cfg.GetInt(TokenStudentIdViperKey.Key()); id > 0 {
+					info.StudentId = id
+				}
+
+				profiles = append(profiles, info)
+			}
+
+			return profileOpts.printer.Print(output.View{
+				Data: profiles,
+				Table: func(t *output.Table) {
+					profilesTable(t, profiles)
+				},
+			})
+		},
+	}
+	profileAddCmd = &cobra.Command{
+		Use:   "add <name> [url]",
+		Short: "Creates a profile, log in with 'gaps-cli --profile <name> login' afterwards",
+		Example: `  gaps-cli profile add assistant
+  gaps-cli profile add test https://gaps-test.heig-vd.ch --use`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if err := validateProfileName(name); err != nil {
+				return err
+			}
+
+			var url any = "https://gaps.heig-vd.ch"
+			if len(args) > 1 {
+				var err error
+				if url, err = parseConfigValue(configKeys[UrlViperKey], args[1]); err != nil {
+					return fmt.Errorf("invalid url: %w", err)
+				}
+			}
+
+			dir := profileDirectory(name)
+			if _, err := os.Stat(dir); err == nil {
+				return fmt.Errorf("profile %s already exists", name)
+			}
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				return err
+			}
+
+			cfg := viper.New()
+			cfg.SetConfigPermissions(0600)
+			cfg.Set(UrlViperKey.Key(), url)
+			if err := cfg.WriteConfigAs(filepath.Join(dir, "gaps.yaml")); err != nil {
+				return err
+			}
+
+			fmt.Printf("Created profile %s\n", name)
+			if profileOpts.use {
+				return useProfile(name)
+			}
+
+			return nil
+		},
+	}
+	profileUseCmd = &cobra.Command{
+		Use:   "use <name>",
+		Short: "Selects the profile used when --profile isn't given",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !profileExists(args[0]) {
+				return fmt.Errorf("no profile named %s", args[0])
+			}
+
+			return useProfile(args[0])
+		},
+	}
+	profileRemoveCmd = &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Deletes a profile along with its configuration, credentials and history",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			switch {
+			case name == defaultProfile:
+				return errors.New("the default profile can't be removed")
+			case !profileExists(name):
+				return fmt.Errorf("no profile named %s", name)
+			}
+
+			if !profileOpts.yes && !confirm(fmt.Sprintf("Delete profile %s and its history?", name)) {
+				return errors.New("aborted, use --yes to remove it without confirmation")
+			}
+			active := readActiveProfile() == name
+
+			keyring := credentials.NewKeyring(keyringService(name))
+			if keyring.Available() {
+				keys := make([]string, 0, len(secretKeys))
+				for _, key := range secretKeys {
+					keys = append(keys, key.Key())
+				}
+
+				// the passwords of the scraped accounts are listed in the configuration of the profile
+				cfg := viper.New()
+				cfg.SetConfigFile(filepath.Join(profileDirectory(name), "gaps.yaml"))
+				if err := cfg.ReadInConfig(); err == nil {
+					keys = append(keys, accountPasswordKeys(cfg)...)
+				}
+
+				for _, key := range keys {
+					if err := keyring.Delete(key); err != nil {
+						log.WithError(err).Warnf("Could not remove %s from the keyring", key)
+					}
+				}
+			}
+
+			if err := os.RemoveAll(profileDirectory(name)); err != nil {
+				return err
+			}
+
+			fmt.Printf("Removed profile %s\n", name)
+			if active {
+				return useProfile(defaultProfile)
+			}
+
+			return nil
+		},
+	}
+)
+
+func init() {
+	profileOpts.printer.AddFlags(profileListCmd.Flags(), output.TableFormat)
+	profileAddCmd.Flags().BoolVar(&profileOpts.use, "use", false, "select the new profile")
+	profileRemoveCmd.Flags().BoolVarP(&profileOpts.yes, "yes", "y", false, "don't ask for confirmation")
+
+	profileCmd.AddCommand(profileListCmd, profileAddCmd, profileUseCmd, profileRemoveCmd)
+	rootCmd.AddCommand(profileCmd)
+}
+
+// resolveProfile returns the profile given by --profile, GAPS_PROFILE or 'gaps-cli profile use', in this order.
+func resolveProfile() string {
+	name := profileName
+	if name == "" {
+		name = os.Getenv(envPrefix + "_PROFILE")
+	}
+	if name == "" {
+		return readActiveProfile()
+	}
+
+	util.CheckErr(validateProfileName(name))
+	if !profileExists(name) {
+		log.Fatalf("No profile named %s, create it with 'gaps-cli profile add %s'", name, name)
+	}
+
+	return name
+}
+
+// getProfileDirectory returns the directory of the configuration, credentials and history of the active profile.
+func getProfileDirectory() string {
+	dir := profileDirectory(activeProfile)
+	util.CheckErr(os.MkdirAll(dir, 0700))
+	return dir
+}
+
+func profileDirectory(name string) string {
+	dir := filepath.Join(getConfigDirectory(), "gaps-cli")
+	if name == defaultProfile {
+		return dir
+	}
+
+	return filepath.Join(dir, "profiles", name)
+}
+
+// setProfileDefaults points the history files to the profile directory unless configured otherwise.
+func setProfileDefaults(dir string) {
+	defaultViper.SetDefault(HistoryStoreFileViperKey.Key(), dir+"/history.db")
+	defaultViper.SetDefault(GradesHistoryFileViperKey.Key(), dir+"/grades-history.json")
+	defaultViper.SetDefault(AbsencesHistoryFileViperKey.Key(), dir+"/absences-history.json")
+	defaultViper.SetDefault(ReportCardHistoryFileViperKey.Key(), dir+"/report-card-history.json")
+	defaultViper.SetDefault(ScheduleHistoryFileViperKey.Key(), dir+"/schedule-history.json")
+}
+
+// setLegacyStoreDefault keeps the history database next to the legacy history files when they are configured
+// outside the profile directory, e.g. on the volume of a container.
+func setLegacyStoreDefault(cmd *cobra.Command, dir string) {
+	if defaultViper.GetString(HistoryStoreFileViperKey.Key()) != dir+"/history.db" {
+		return
+	}
+
+	legacy := []struct {
+		key  ViperKey
+		file string
+	}{
+		{GradesHistoryFileViperKey, "grades-history.json"},
+		{AbsencesHistoryFileViperKey, "absences-history.json"},
+		{ReportCardHistoryFileViperKey, "report-card-history.json"},
+		{ScheduleHistoryFileViperKey, "schedule-history.json"},
+	}
+	for _, l := range legacy {
+		file := defaultViper.GetString(l.key.Key())
+		if file == "" || file == dir+"/"+l.file {
+			continue
+		}
+
+		path := filepath.Join(filepath.Dir(file), "history.db")
+		defaultViper.SetDefault(HistoryStoreFileViperKey.Key(), path)
+		if flag := cmd.Flags().Lookup(HistoryStoreFileViperKey.Flag()); flag != nil && (!flag.Changed || boundFlags[flag.Name]) {
+			cmd.Flags().Set(flag.Name, path)
+			boundFlags[flag.Name] = true
+		}
+		return
+	}
+}
+
+func validateProfileName(name string) error {
+	if !profileNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q, use letters, digits, - and _", name)
+	}
+
+	return nil
+}
+
+func profileExists(name string) bool {
+	if name == defaultProfile {
+		return true
+	}
+
+	info, err := os.Stat(profileDirectory(name))
+	return err == nil && info.IsDir()
+}
+
+func listProfiles() ([]string, error) {
+	names := []string{defaultProfile}
+	entries, err := os.ReadDir(filepath.Join(getConfigDirectory(), "gaps-cli", "profiles"))
+	if errors.Is(err, os.ErrNotExist) {
+		return names, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() && profileNameRegex.MatchString(entry.Name()) && entry.Name() != defaultProfile {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names, nil
+}
+
+func readActiveProfile() string {
+	data, err := os.ReadFile(filepath.Join(getConfigDirectory(), "gaps-cli", activeProfileFile))
+	if err != nil {
+		return defaultProfile
+	}
+
+	name := strings.TrimSpace(string(data))
+	if validateProfileName(name) != nil || !profileExists(name) {
+		log.Warnf("Active profile %q doesn't exist anymore, using the default one", name)
+		return defaultProfile
+	}
+
+	return name
+}
+
+func useProfile(name string) error {
+	path := filepath.Join(getConfigDirectory(), "gaps-cli", activeProfileFile)
+	if name == defaultProfile {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	} else if err := os.WriteFile(path, []byte(name+"\n"), 0600); err != nil {
+		return err
+	}
+
+	fmt.Printf("Using profile %s\n", name)
+	return nil
+}
+
+// readProfileConfig reads the configuration file of a profile, without environment or flags overrides.
+func readProfileConfig(name string) *viper.Viper {
+	cfg := viper.New()
+	cfg.SetConfigFile(filepath.Join(profileDirectory(name), "gaps.yaml"))
+	if err := cfg.ReadInConfig(); err != nil {
+		log.WithError(err).Debugf("Could not read the configuration of profile %s", name)
+	}
+
+	return cfg
+}
+
+func confirm(question string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func profilesTable(t *output.Table, profiles []*profileInfo) {
+	t.AppendHeader(table.Row{"", "Profile", "URL", "Username", "Student id"})
+	for _, p := range profiles {
+		marker := ""
+		if p.Active {
+			marker = "*"
+		}
+
+		studentId := ""
+		if p.StudentId > 0 {
+			studentId = fmt.Sprint(p.StudentId)
+		}
+
+		t.AppendRow(table.Row{marker, p.Name, p.Url, p.Username, studentId})
+	}
+}
@@ -1,21 +1,19 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/output"
 	"lutonite.dev/gaps-cli/parser"
 	"lutonite.dev/gaps-cli/util"
-	"os"
-	"strconv"
 )
 
 type ReportCardCmdOpts struct {
-	format string
+	printer output.Printer
 }
 
 var (
@@ -24,10 +22,13 @@ var (
 		Use:   "report-card",
 		Short: "Allows to consult your report card",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg := buildTokenClientConfiguration()
+			cfg, err := buildTokenClientConfiguration(cmd.Context(), rememberToken)
+			if err != nil {
+				return err
+			}
 
 			action := gaps.NewReportCardAction(cfg)
-			reports, err := action.FetchReportCard()
+			reports, err := action.FetchReportCardContext(cmd.Context())
 			util.CheckErr(err)
 
 			if len(reports) == 0 {
@@ -35,25 +36,26 @@ var (
 				return nil
 			}
 
-			if reportCardOpts.format == "json" {
-				return json.NewEncoder(os.Stdout).Encode(reports)
-			}
-
-			reportCardOpts.PrintReportCardTable(reports)
-			return nil
+			return reportCardOpts.printer.Print(output.View{
+				Data: reports,
+				Table: func(t *output.Table) {
+					reportCardOpts.BuildReportCardTable(t, reports)
+				},
+				Records: func(t *output.Table) {
+					reportCardOpts.BuildReportCardRecords(t, reports)
+				},
+			})
 		},
 	}
 )
 
 func init() {
-	reportCardCmd.Flags().StringVarP(&reportCardOpts.format, "format", "o", "table", "Output format (table, json)")
+	reportCardOpts.printer.AddFlags(reportCardCmd.Flags(), output.TableFormat)
 
 	rootCmd.AddCommand(reportCardCmd)
 }
 
-func (g *ReportCardCmdOpts) PrintReportCardTable(moduleReports []*parser.ModuleReport) {
-	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
+func (g *ReportCardCmdOpts) BuildReportCardTable(t *output.Table, moduleReports []*parser.ModuleReport) {
 	t.Style().Options.SeparateRows = true
 	t.SetColumnConfigs([]table.ColumnConfig{
 		{Number: 1, AutoMerge: true},
@@ -116,25 +118,77 @@ func (g *ReportCardCmdOpts) PrintReportCardTable(moduleReports []*parser.ModuleR
 		"",
 		"",
 		"WEIGHTED GPA",
-		fmt.Sprintf("%.2f", computeGpa(moduleReports)),
+		computeGpa(moduleReports).Format(2),
 	}, table.RowConfig{AutoMerge: true})
+}
+
+// BuildReportCardRecords lists a row per unit grade for spreadsheets, the columns must keep their order for
+// re-imports. Units without grades and modules without units still get a row, with empty grade or unit columns.
+func (g *ReportCardCmdOpts) BuildReportCardRecords(t *output.Table, moduleReports []*parser.ModuleReport) {
+	t.Name = "Report card"
+	t.AppendHeader(table.Row{
+		"year", "module", "module_name", "credits", "situation", "module_grade",
+		"unit", "unit_name", "unit_weight", "unit_mean", "category", "weight", "grade",
+	})
+
+	for _, module := range moduleReports {
+		var year any
+		if module.Year > 0 {
+			year = module.Year
+		}
+
+		moduleCells := table.Row{
+			year,
+			module.Identifier,
+			module.Name,
+			module.Credits,
+			module.Situation,
+			gradeCell(module.GlobalGradeValue, module.GlobalGrade),
+		}
+
+		if len(module.Classes) == 0 {
+			t.AppendRow(append(moduleCells, nil, nil, nil, nil, nil, nil, nil))
+			continue
+		}
 
-	t.Render()
+		for _, unit := range module.Classes {
+			unitCells := append(append(table.Row{}, moduleCells...),
+				unit.Identifier,
+				unit.Name,
+				unit.Weight,
+				gradeCell(unit.MeanValue, unit.Mean),
+			)
+
+			if len(unit.Grades) == 0 {
+				t.AppendRow(append(unitCells, nil, nil, nil))
+				continue
+			}
+
+			for _, grade := range unit.Grades {
+				t.AppendRow(append(append(table.Row{}, unitCells...),
+					grade.Name,
+					grade.Weight,
+					gradeCell(grade.Value, grade.Grade),
+				))
+			}
+		}
+	}
 }
 
-func computeGpa(grades []*parser.ModuleReport) float64 {
-	var totalCredits uint
-	var totalPoints float64
+// computeGpa weights the grades of the passed modules with their credits, the GPA is not graded until a module
+// with credits is passed.
+func computeGpa(grades []*parser.ModuleReport) parser.GradeValue {
+	var values []parser.GradeValue
+	var credits []float64
 
 	for _, module := range grades {
 		if module.Situation != "Réussite" {
 			continue
 		}
 
-		totalCredits += module.Credits
-		gradeNumeric, _ := strconv.ParseFloat(module.GlobalGrade, 64)
-		totalPoints += float64(module.Credits) * gradeNumeric
+		values = append(values, module.GlobalGradeValue)
+		credits = append(credits, float64(module.Credits))
 	}
 
-	return totalPoints / float64(totalCredits)
+	return parser.WeightedMean(values, credits)
 }
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"lutonite.dev/gaps-cli/output"
+	"lutonite.dev/gaps-cli/parser"
+)
+
+func TestComputeGpa(t *testing.T) {
+	passed := func(grade float64, credits uint) *parser.ModuleReport {
+		return &parser.ModuleReport{Situation: "Réussite", Credits: credits, GlobalGradeValue: parser.NewGradeValue(grade)}
+	}
+
+	tests := []struct {
+		name    string
+		modules []*parser.ModuleReport
+		want    string
+	}{
+		{"no module", nil, "-"},
+		{"no passed module", []*parser.ModuleReport{{Situation: "En cours", Credits: 4}}, "-"},
+		{"no credits", []*parser.ModuleReport{passed(5, 0)}, "-"},
+		{"weighted by credits", []*parser.ModuleReport{passed(4, 3), passed(6, 1), {Situation: "Échec", Credits: 4}}, "4.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := computeGpa(tt.modules).Format(2); got != tt.want {
+				t.Errorf("computeGpa = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildReportCardRecords(t *testing.T) {
+	modules := []*parser.ModuleReport{
+		{
+			Identifier: "ARN", Name: "Apprentissage", Credits: 4, Situation: "En cours",
+			Classes: []*parser.ModuleClass{
+				{Identifier: "ARN-C", Name: "Cours", Weight: 60, Grades: []*parser.ClassGrade{
+					{Name: "Contrôle continu", Weight: 50, Grade: "4.5", Value: parser.NewGradeValue(4.5)},
+					{Name: "Examen", Weight: 50, Grade: "-"},
+				}},
+				{Identifier: "ARN-L", Name: "Laboratoire", Weight: 40},
+			},
+		},
+		{Identifier: "PRO", Name: "Projet", Credits: 6, Situation: "En cours"},
+	}
+
+	path := t.TempDir() + "/report-card.csv"
+	printer := output.Printer{Format: output.CsvFormat, Output: path}
+	err := printer.Print(output.View{
+		Records: func(t *output.Table) {
+			reportCardOpts.BuildReportCardRecords(t, modules)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"year,module,module_name,credits,situation,module_grade,unit,unit_name,unit_weight,unit_mean,category,weight,grade",
+		",ARN,Apprentissage,4,En cours,,ARN-C,Cours,60,,Contrôle continu,50,4.5",
+		",ARN,Apprentissage,4,En cours,,ARN-C,Cours,60,,Examen,50,",
+		",ARN,Apprentissage,4,En cours,,ARN-L,Laboratoire,40,,,,",
+		",PRO,Projet,6,En cours,,,,,,,,",
+	}
+	if got := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"); !reflect.DeepEqual(got, want) {
+		t.Errorf("records =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -1,14 +1,21 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"lutonite.dev/gaps-cli/credentials"
+	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/output"
 	"lutonite.dev/gaps-cli/util"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 )
 
 type ViperKey string
@@ -16,6 +23,7 @@ type ViperKey string
 func viperKey(key string, flag string) ViperKey {
 	k := ViperKey(key)
 	flagMapping[flag] = k
+	viperKeys = append(viperKeys, k)
 	return k
 }
 
@@ -37,17 +45,36 @@ const (
 )
 
 var (
-	UrlViperKey               = viperKey("url", "url")
-	GradesHistoryFileViperKey = viperKey("history.grades.file", "history")
-	UsernameViperKey          = viperKey("login.username", "username")
-	PasswordViperKey          = viperKey("login.password", "password")
-	ScraperApiUrlViperKey     = viperKey("scraper.api.url", "api-url")
-	ScraperApiKeyViperKey     = viperKey("scraper.api.key", "api-key")
-	TokenValueViperKey        = viperKey("login.token.value", "")
-	TokenStudentIdViperKey    = viperKey("login.token.studentId", "")
-	TokenDateValueViperKey    = viperKey("login.token.generatedAt", "")
+	UrlViperKey                       = viperKey("url", "url")
+	HistoryStoreFileViperKey          = viperKey("history.store.file", "store")
+	GradesHistoryFileViperKey         = viperKey("history.grades.file", "history")
+	AbsencesHistoryFileViperKey       = viperKey("history.absences.file", "absences-history")
+	ReportCardHistoryFileViperKey     = viperKey("history.report-card.file", "report-card-history")
+	ScheduleHistoryFileViperKey       = viperKey("history.schedule.file", "schedule-history")
+	UsernameViperKey                  = viperKey("login.username", "username")
+	PasswordViperKey                  = viperKey("login.password", "password")
+	ScraperApiUrlViperKey             = viperKey("scraper.api.url", "api-url")
+	ScraperApiKeyViperKey             = viperKey("scraper.api.key", "api-key")
+	ScraperNotifiersViperKey          = viperKey("scraper.notifiers", "")
+	ScraperAccountsViperKey           = viperKey("scraper.accounts", "")
+	ScraperRateLimitViperKey          = viperKey("scraper.rate-limit", "rate-limit")
+	ScraperMetricsListenViperKey      = viperKey("scraper.metrics.listen", "metrics-listen")
+	ScraperAbsencesViperKey           = viperKey("scraper.absences.enabled", "absences")
+	ScraperReportCardViperKey         = viperKey("scraper.report-card.enabled", "report-card")
+	ScraperScheduleViperKey           = viperKey("scraper.schedule.enabled", "schedule")
+	ScraperScheduleLookaheadViperKey  = viperKey("scraper.schedule.lookahead", "schedule-lookahead")
+	AbsenceRelativeThresholdsViperKey = viperKey("scraper.absences.thresholds.relative", "absence-relative-thresholds")
+	AbsenceAbsoluteThresholdsViperKey = viperKey("scraper.absences.thresholds.absolute", "absence-absolute-thresholds")
+	TokenValueViperKey                = viperKey("login.token.value", "")
+	TokenStudentIdViperKey            = viperKey("login.token.studentId", "")
+	TokenDateValueViperKey            = viperKey("login.token.generatedAt", "")
+	ServeIcalTokenViperKey            = viperKey("serve.ical.token", "token")
+	CredentialsBackendViperKey        = viperKey("credentials.backend", "credentials-backend")
+	CredentialsFileViperKey           = viperKey("credentials.file", "")
 
 	flagMapping = make(map[string]ViperKey)
+	// viperKeys lists every key, in declaration order
+	viperKeys []ViperKey
 )
 
 var (
@@ -57,6 +84,18 @@ var (
 	cfgFile     string
 	credsFile   string
 	loggerLevel string
+	recordDir   string
+	replayDir   string
+	noColor     bool
+
+	// boundFlags are the flags set from the configuration rather than the command line
+	boundFlags = make(map[string]bool)
+
+	// tokenPending tells that a token was refreshed since the configuration was last written
+	tokenPending bool
+
+	// transport used by every GAPS client when recording or replaying, nil otherwise
+	transport http.RoundTripper
 
 	rootCmd = &cobra.Command{
 		Use:   "gaps-cli",
@@ -71,22 +110,43 @@ var (
 )
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// cancel in-flight requests to GAPS when the process is asked to stop
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// commands failing through log.Fatal exit without returning
+	log.RegisterExitHandler(writePendingToken)
+
+	err := rootCmd.ExecuteContext(ctx)
+	writePendingToken()
+	if err != nil {
 		cobra.CheckErr(err)
 	}
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "auth config file (default is $HOME/.config/gaps-cli/gaps.yaml)")
-	rootCmd.PersistentFlags().StringVar(&credsFile, "credentials", "", "credentials config file (default is $HOME/.config/gaps-cli/credentials.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "profile to use, see 'gaps-cli profile' (default is the active profile)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "auth config file (default is gaps.yaml in the profile directory)")
+	rootCmd.PersistentFlags().StringVar(&credsFile, "credentials", "", "plaintext credentials file (default is credentials.yaml in the profile directory)")
+	rootCmd.PersistentFlags().String(CredentialsBackendViperKey.Flag(), "", "where to store credentials: keyring, file or plaintext (default is the keyring when available, an encrypted file otherwise)")
 	rootCmd.PersistentFlags().StringVar(&loggerLevel, "log-level", "error", "logging level")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colors in tables and logs (also disabled when NO_COLOR is set)")
 	rootCmd.PersistentFlags().String(UrlViperKey.Flag(), "", "GAPS URL (default is https://gaps.heig-vd.ch/)")
+	rootCmd.PersistentFlags().StringVar(&recordDir, "record", "", "record every GAPS request and response to this directory, redacted")
+	rootCmd.PersistentFlags().StringVar(&replayDir, "replay", "", "serve GAPS requests from the captures of this directory instead of the network")
+	rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
 
 	defaultViper.BindPFlag(UrlViperKey.Key(), rootCmd.PersistentFlags().Lookup(UrlViperKey.Flag()))
 	defaultViper.SetDefault(UrlViperKey.Key(), "https://gaps.heig-vd.ch")
+	defaultViper.BindPFlag(CredentialsBackendViperKey.Key(), rootCmd.PersistentFlags().Lookup(CredentialsBackendViperKey.Flag()))
 }
 
 func initializeConfig(cmd *cobra.Command) {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		output.DisableColors()
+		log.SetFormatter(&log.TextFormatter{DisableColors: true})
+	}
+
 	if loggerLevel != "" {
 		level, err := log.ParseLevel(loggerLevel)
 		util.CheckErr(err)
@@ -94,9 +154,52 @@ func initializeConfig(cmd *cobra.Command) {
 		log.Tracef("log level set to %s", level)
 	}
 
-	configDir := getConfigDirectory()
+	activeProfile = resolveProfile()
+	log.Debugf("using profile %s", activeProfile)
+
+	configDir := getProfileDirectory()
+	setProfileDefaults(configDir)
 	initViper(cmd, defaultViper, "gaps", configDir, cfgFile)
-	initViper(cmd, credentialsViper, "credentials", configDir, credsFile)
+	setLegacyStoreDefault(cmd, configDir)
+	initCredentials(cmd)
+	initTransport()
+}
+
+func initTransport() {
+	switch {
+	case recordDir != "":
+		recorder, err := gaps.NewRecorder(recordDir, nil)
+		util.CheckErr(err)
+		recorder.Redact(
+			defaultViper.GetString(UsernameViperKey.Key()),
+			credentialsViper.GetString(PasswordViperKey.Key()),
+			credentialsViper.GetString(TokenValueViperKey.Key()),
+		)
+		if studentId := defaultViper.GetInt(TokenStudentIdViperKey.Key()); studentId > 0 {
+			recorder.RedactStudentId(uint(studentId))
+		}
+		recorder.AcademicYear = currentAcademicYear()
+
+		log.WithField("dir", recordDir).Info("Recording GAPS requests")
+		transport = recorder
+	case replayDir != "":
+		replayer, err := gaps.NewReplayer(replayDir)
+		util.CheckErr(err)
+		replayer.AcademicYear = currentAcademicYear()
+
+		log.WithField("dir", replayDir).Info("Replaying GAPS requests")
+		transport = replayer
+	}
+}
+
+func newClientConfiguration() *gaps.ClientConfiguration {
+	cfg := new(gaps.ClientConfiguration)
+	cfg.Init(defaultViper.GetString(UrlViperKey.Key()))
+	if transport != nil {
+		cfg.SetTransport(transport)
+	}
+
+	return cfg
 }
 
 func getConfigDirectory() string {
@@ -112,12 +215,14 @@ func getConfigDirectory() string {
 }
 
 func initViper(cmd *cobra.Command, v *viper.Viper, name string, configDir string, path string) {
+	// the configuration may hold the passwords of scraped accounts and notifier tokens
+	v.SetConfigPermissions(0600)
 	if path != "" {
-		v.SetConfigFile(cfgFile)
+		v.SetConfigFile(path)
 	} else {
 		v.AddConfigPath(configDir)
 		v.SetConfigType("yaml")
-		v.SetConfigName("gaps-cli/" + name)
+		v.SetConfigName(name)
 	}
 
 	log.Debugf("writing config file %s", v.ConfigFileUsed())
@@ -127,8 +232,14 @@ func initViper(cmd *cobra.Command, v *viper.Viper, name string, configDir string
 
 	if err := v.ReadInConfig(); err == nil {
 		log.WithField("file", v.ConfigFileUsed()).Infof("Reading global config file")
+		util.CheckErr(credentials.Restrict(v.ConfigFileUsed()))
 	}
 
+	initEnv(cmd, v)
+}
+
+// initEnv lets environment variables and command flags override the values of v.
+func initEnv(cmd *cobra.Command, v *viper.Viper) {
 	v.SetEnvPrefix(envPrefix)
 	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
 	v.AutomaticEnv()
@@ -140,13 +251,24 @@ func bindFlags(cmd *cobra.Command, v *viper.Viper) {
 	cmd.Flags().VisitAll(func(f *pflag.Flag) {
 		configName := flagMapping[f.Name]
 		if !f.Changed && v.IsSet(configName.Key()) {
-			val := v.Get(configName.Key())
-			cmd.Flags().Set(f.Name, fmt.Sprintf("%v", val))
+			value := fmt.Sprintf("%v", v.Get(configName.Key()))
+			// slice flags take comma separated values
+			if strings.HasSuffix(f.Value.Type(), "Slice") {
+				value = strings.Join(v.GetStringSlice(configName.Key()), ",")
+			}
+			cmd.Flags().Set(f.Name, value)
+			boundFlags[f.Name] = true
 		}
 	})
 }
 
 func writeConfig() {
+	// replayed sessions must never overwrite the real credentials
+	if replayDir != "" {
+		return
+	}
+
+	writeCredentials()
 	defaultViper.WriteConfig()
-	credentialsViper.WriteConfig()
+	tokenPending = false
 }
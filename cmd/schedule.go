@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	ics "github.com/arran4/golang-ical"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/output"
+)
+
+const scheduleDateFormat = "2006-01-02"
+
+type ScheduleCmdOpts struct {
+	printer  output.Printer
+	view     string
+	year     uint
+	semester gaps.Semester
+	teacher  uint
+	room     uint
+	from     string
+	to       string
+	export   string
+	raw      bool
+}
+
+var (
+	scheduleOpts = &ScheduleCmdOpts{
+		semester: currentSemester(),
+	}
+
+	scheduleCmd = &cobra.Command{
+		Use:   "schedule",
+		Short: "Allows to consult your schedule, or the one of a teacher or a room",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, to, err := scheduleOpts.dateRange()
+			if err != nil {
+				return err
+			}
+
+			cfg, err := buildTokenClientConfiguration(cmd.Context(), rememberToken)
+			if err != nil {
+				return err
+			}
+			schedType, target := scheduleOpts.target()
+
+			calendars, err := fetchScheduleTerms(cmd.Context(), log.NewEntry(log.StandardLogger()), cfg,
+				scheduleOpts.year, scheduleOpts.semester.ScheduleTerms(), schedType, target)
+			if err != nil {
+				return err
+			}
+
+			var calendar *ics.Calendar
+			switch {
+			case scheduleOpts.raw && len(calendars) == 1:
+				// merging drops the timezones and properties of the calendar served by GAPS
+				calendar = calendars[0]
+			case scheduleOpts.raw:
+				calendar = gaps.MergeCalendars(calendars...)
+			default:
+				calendar = gaps.FilterCalendar(gaps.MergeCalendars(calendars...), from, to)
+			}
+
+			if scheduleOpts.export != "" {
+				return exportCalendar(calendar, scheduleOpts.export)
+			}
+
+			lessons := gaps.Lessons(calendar)
+			return scheduleOpts.printer.Print(output.View{
+				Data: lessons,
+				Table: func(t *output.Table) {
+					if scheduleOpts.view == "week" {
+						scheduleWeeksTable(t, lessons)
+					} else {
+						scheduleDaysTable(t, lessons)
+					}
+				},
+			})
+		},
+	}
+)
+
+func init() {
+	scheduleOpts.printer.AddFlags(scheduleCmd.Flags(), output.TableFormat)
+	scheduleCmd.Flags().StringVar(&scheduleOpts.view, "view", "day", "Table layout (day, week)")
+	scheduleCmd.Flags().UintVarP(&scheduleOpts.year, "year", "y", currentAcademicYear(),
+		"Academic year (year at the start of the academic year, e.g. 2020 for 2020-2021 academic year)")
+	scheduleCmd.Flags().VarP(&scheduleOpts.semester, "semester", "s", "Academic semester (S1, S2, all)")
+	scheduleCmd.Flags().UintVar(&scheduleOpts.teacher, "teacher", 0, "GAPS id of the teacher to get the schedule of")
+	scheduleCmd.Flags().UintVar(&scheduleOpts.room, "room", 0, "GAPS id of the room to get the schedule of")
+	scheduleCmd.Flags().StringVar(&scheduleOpts.from, "from", "", "First day to show, YYYY-MM-DD (default is today)")
+	scheduleCmd.Flags().StringVar(&scheduleOpts.to, "to", "", "Last day to show, YYYY-MM-DD (default is a week after --from)")
+	scheduleCmd.Flags().StringVarP(&scheduleOpts.export, "export", "e", "", "Export the calendar as iCal to a file, - for stdout")
+	scheduleCmd.Flags().BoolVar(&scheduleOpts.raw, "raw", false, "Do not filter the calendar by date")
+	scheduleCmd.MarkFlagsMutuallyExclusive("teacher", "room")
+
+	rootCmd.AddCommand(scheduleCmd)
+}
+
+// fetchScheduleTerms fetches a schedule term by term. Like for the classes, a failing term is skipped as some
+// terms (e.g. the third one) are often not published yet, the fetch only fails when no term could be fetched.
+func fetchScheduleTerms(
+	ctx context.Context, logger *log.Entry, cfg *gaps.TokenClientConfiguration,
+	year uint, terms []uint, schedType gaps.ScheduleType, target uint,
+) ([]*ics.Calendar, error) {
+	var calendars []*ics.Calendar
+	var fetchErr error
+	for _, term := range terms {
+		calendar, err := gaps.NewScheduleAction(cfg, year, term, schedType, target).FetchScheduleContext(ctx)
+		if err != nil {
+			fetchErr = fmt.Errorf("couldn't fetch %s schedule: %w", schedType, err)
+			if len(terms) > 1 {
+				logger.WithError(err).Warnf("Couldn't fetch the %s schedule of term %d, skipping it", schedType, term)
+			}
+			continue
+		}
+
+		calendars = append(calendars, calendar)
+	}
+
+	if len(calendars) == 0 {
+		return nil, fetchErr
+	}
+
+	return calendars, nil
+}
+
+func (o *ScheduleCmdOpts) target() (gaps.ScheduleType, uint) {
+	switch {
+	case o.teacher != 0:
+		return gaps.TeacherSchedule, o.teacher
+	case o.room != 0:
+		return gaps.RoomSchedule, o.room
+	default:
+		return gaps.StudentSchedule, 0
+	}
+}
+
+// dateRange returns the [from, to) range of the days to show, in local time.
+func (o *ScheduleCmdOpts) dateRange() (time.Time, time.Time, error) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if o.from != "" {
+		var err error
+		if from, err = time.ParseInLocation(scheduleDateFormat, o.from, time.Local); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --from date: %w", err)
+		}
+	}
+
+	to := from.AddDate(0, 0, 7)
+	if o.to != "" {
+		last, err := time.ParseInLocation(scheduleDateFormat, o.to, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --to date: %w", err)
+		}
+		to = last.AddDate(0, 0, 1)
+	}
+
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("--to must not be before --from")
+	}
+
+	return from, to, nil
+}
+
+func exportCalendar(calendar *ics.Calendar, path string) error {
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return calendar.SerializeTo(w)
+}
+
+func scheduleDaysTable(t *output.Table, lessons []*gaps.Lesson) {
+	t.Style().Options.SeparateRows = true
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 1, AutoMerge: true},
+		{Number: 2, Align: text.AlignCenter, AlignHeader: text.AlignCenter},
+		{Number: 4, Align: text.AlignCenter, AlignHeader: text.AlignCenter},
+	})
+
+	t.AppendHeader(table.Row{"Day", "Time", "Lesson", "Location"})
+	for _, lesson := range lessons {
+		start := lesson.Start.Local()
+		t.AppendRow(table.Row{
+			start.Format("Mon 02.01.2006"),
+			fmt.Sprintf("%s - %s", start.Format("15:04"), lesson.End.Local().Format("15:04")),
+			lesson.Summary,
+			lesson.Location,
+		})
+	}
+}
+
+// scheduleWeeksTable shows a section per week, with a column per weekday and a row per start time.
+func scheduleWeeksTable(t *output.Table, lessons []*gaps.Lesson) {
+	type slotKey struct {
+		week string
+		time string
+	}
+
+	var weeks []string
+	var slots = make(map[string][]string)
+	var cells = make(map[slotKey][]string)
+
+	for _, lesson := range lessons {
+		start := lesson.Start.Local()
+		year, w := start.ISOWeek()
+		week := fmt.Sprintf("%d-W%02d", year, w)
+		slot := fmt.Sprintf("%s - %s", start.Format("15:04"), lesson.End.Local().Format("15:04"))
+
+		if _, ok := slots[week]; !ok {
+			weeks = append(weeks, week)
+		}
+		if !containsString(slots[week], slot) {
+			slots[week] = append(slots[week], slot)
+		}
+
+		day := (int(start.Weekday()) + 6) % 7
+		key := slotKey{week, slot}
+		if cells[key] == nil {
+			cells[key] = make([]string, 7)
+		}
+
+		entry := lesson.Summary
+		if lesson.Location != "" {
+			entry += "\n" + lesson.Location
+		}
+		if cells[key][day] != "" {
+			entry = cells[key][day] + "\n" + entry
+		}
+		cells[key][day] = entry
+	}
+
+	t.Style().Options.SeparateRows = true
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 1, AutoMerge: true},
+	})
+
+	t.AppendHeader(table.Row{"Week", "Time", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"})
+	for _, week := range weeks {
+		sort.Strings(slots[week])
+		for _, slot := range slots[week] {
+			row := table.Row{week, slot}
+			for _, cell := range cells[slotKey{week, slot}] {
+				row = append(row, cell)
+			}
+			t.AppendRow(row)
+		}
+
+		t.AppendSeparator()
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
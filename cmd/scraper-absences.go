@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/notifier"
+	"lutonite.dev/gaps-cli/parser"
+	"lutonite.dev/gaps-cli/store"
+)
+
+func (s *ScraperCommand) scrapeAbsences(ctx context.Context, cfg *gaps.TokenClientConfiguration, year uint) error {
+	report, err := gaps.NewAbsencesAction(cfg, year).FetchAbsencesContext(ctx)
+	if err != nil {
+		s.log.Error("Failed to fetch absences")
+		return err
+	}
+
+	var previous parser.AbsenceReport
+	found, err := s.store.Latest(store.Absences, &previous)
+	if !found {
+		if err != nil {
+			s.log.Error("Failed to read previous absences")
+			return err
+		}
+
+		s.log.Info("No previous absences found, overwriting")
+		return s.commit(store.Absences, report)
+	}
+
+	events := diffAbsences(&previous, report,
+		defaultViper.GetIntSlice(AbsenceRelativeThresholdsViperKey.Key()),
+		defaultViper.GetIntSlice(AbsenceAbsoluteThresholdsViperKey.Key()),
+	)
+	if len(events) == 0 {
+		s.log.Info("No absence changes found")
+	}
+
+	for _, event := range events {
+		a := event.Absence
+		s.log.WithFields(log.Fields{
+			"reason":        a.Reason,
+			"unjustified":   a.Unjustified,
+			"relative-rate": a.RelativeRate,
+			"absolute-rate": a.AbsoluteRate,
+		}).Infof("ABSENCE [%s] %s", a.Course, a.Reason)
+		s.enqueue(event)
+	}
+
+	return s.commit(store.Absences, report)
+}
+
+// diffAbsences compares two absence reports course by course. Thresholds only fire when a rate goes from below
+// to at or above them, so a course staying above a threshold is only reported once.
+func diffAbsences(previous *parser.AbsenceReport, current *parser.AbsenceReport, relative []int, absolute []int) []*notifier.Event {
+	previousCourses := make(map[string]*parser.CourseAbsence)
+	for i := range previous.Courses {
+		previousCourses[previous.Courses[i].Name] = &previous.Courses[i]
+	}
+
+	var events []*notifier.Event
+	now := time.Now()
+	for i := range current.Courses {
+		course := &current.Courses[i]
+		before, ok := previousCourses[course.Name]
+		if !ok {
+			before = &parser.CourseAbsence{Name: course.Name}
+		}
+
+		emit := func(reason notifier.AbsenceReason, rate string, threshold int) {
+			events = append(events, &notifier.Event{
+				Type: notifier.AbsenceEvent,
+				Time: now,
+				Absence: &notifier.AbsenceChange{
+					Reason:              reason,
+					Course:              course.Name,
+					Total:               course.Total,
+					Justified:           course.Justified,
+					Unjustified:         course.Unjustified(),
+					PreviousUnjustified: before.Unjustified(),
+					RelativeRate:        course.RelativeRate(),
+					AbsoluteRate:        course.AbsoluteRate(),
+					Rate:                rate,
+					Threshold:           float64(threshold),
+				},
+			})
+		}
+
+		if course.Unjustified() > before.Unjustified() {
+			emit(notifier.AbsenceUnjustified, "", 0)
+		}
+		if newlyJustified(before, course) > 0 {
+			emit(notifier.AbsenceJustified, "", 0)
+		}
+
+		for _, threshold := range relative {
+			if crossed(before.RelativeRate(), course.RelativeRate(), threshold) {
+				emit(notifier.AbsenceThreshold, "relative", threshold)
+			}
+		}
+		for _, threshold := range absolute {
+			if crossed(before.AbsoluteRate(), course.AbsoluteRate(), threshold) {
+				emit(notifier.AbsenceThreshold, "absolute", threshold)
+			}
+		}
+	}
+
+	return events
+}
+
+// newlyJustified counts the absences of the previous report that have been justified since. GAPS only gives counts,
+// so in each term justifications are attributed to the new absences first: a new absence that is already justified
+// isn't a justification.
+func newlyJustified(before *parser.CourseAbsence, after *parser.CourseAbsence) int {
+	beforeJustified := before.JustifiedPeriods.Terms()
+	if sum(beforeJustified) != before.Justified {
+		// previous reports didn't record the justified periods of each term
+		return positive(after.Justified - before.Justified - positive(after.Total-before.Total))
+	}
+
+	justified := 0
+	beforePeriods := before.Periods.Terms()
+	afterPeriods := after.Periods.Terms()
+	afterJustified := after.JustifiedPeriods.Terms()
+	for i := range afterPeriods {
+		added := positive(afterPeriods[i] - beforePeriods[i])
+		justified += positive(afterJustified[i] - beforeJustified[i] - added)
+	}
+
+	return justified
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+
+	return total
+}
+
+func positive(n int) int {
+	if n < 0 {
+		return 0
+	}
+
+	return n
+}
+
+func crossed(before float64, after float64, threshold int) bool {
+	return before < float64(threshold) && after >= float64(threshold)
+}
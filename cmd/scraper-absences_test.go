@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"testing"
+
+	"lutonite.dev/gaps-cli/notifier"
+	"lutonite.dev/gaps-cli/parser"
+)
+
+// course has the given periods and justified periods in the first term, out of 100 periods.
+func course(periods int, justified int) parser.CourseAbsence {
+	return parser.CourseAbsence{
+		Name:             "ARN",
+		Periods:          parser.TermPeriods{Term1: periods},
+		JustifiedPeriods: parser.TermPeriods{Term1: justified},
+		Total:            periods,
+		Justified:        justified,
+		RelativePeriods:  100,
+		AbsolutePeriods:  100,
+	}
+}
+
+func TestDiffAbsences(t *testing.T) {
+	tests := []struct {
+		name   string
+		before parser.CourseAbsence
+		after  parser.CourseAbsence
+		want   []notifier.AbsenceReason
+	}{
+		{"unchanged", course(2, 0), course(2, 0), nil},
+		{"new unjustified absence", course(2, 0), course(4, 0), []notifier.AbsenceReason{notifier.AbsenceUnjustified}},
+		{"new justified absence", course(2, 0), course(4, 2), nil},
+		{"previous absence justified", course(2, 0), course(2, 2), []notifier.AbsenceReason{notifier.AbsenceJustified}},
+		{"previous absence justified with a new justified one", course(2, 0), course(4, 3), []notifier.AbsenceReason{notifier.AbsenceJustified}},
+		{"threshold crossed", course(8, 0), course(10, 0), []notifier.AbsenceReason{notifier.AbsenceUnjustified, notifier.AbsenceThreshold}},
+		{"threshold already crossed", course(10, 0), course(12, 0), []notifier.AbsenceReason{notifier.AbsenceUnjustified}},
+		{
+			name:   "previous report without justified periods",
+			before: parser.CourseAbsence{Name: "ARN", Periods: parser.TermPeriods{Term1: 2}, Total: 2, Justified: 1, RelativePeriods: 100},
+			after:  course(4, 3),
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := diffAbsences(
+				&parser.AbsenceReport{Courses: []parser.CourseAbsence{tt.before}},
+				&parser.AbsenceReport{Courses: []parser.CourseAbsence{tt.after}},
+				[]int{10}, nil,
+			)
+
+			var reasons []notifier.AbsenceReason
+			for _, event := range events {
+				reasons = append(reasons, event.Absence.Reason)
+			}
+
+			if len(reasons) != len(tt.want) {
+				t.Fatalf("reasons = %v, want %v", reasons, tt.want)
+			}
+			for i := range reasons {
+				if reasons[i] != tt.want[i] {
+					t.Errorf("reasons = %v, want %v", reasons, tt.want)
+				}
+			}
+		})
+	}
+}
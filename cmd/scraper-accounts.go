@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"lutonite.dev/gaps-cli/credentials"
+	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/metrics"
+	"lutonite.dev/gaps-cli/notifier"
+	"lutonite.dev/gaps-cli/store"
+)
+
+// scraperAccount is an entry of the scraper.accounts list, unset fields fall back to the global scraper settings.
+type scraperAccount struct {
+	Name     string `mapstructure:"name"`
+	Username string `mapstructure:"username"`
+	// Password is kept in the credential store, passwords written in the configuration are moved there
+	Password  string            `mapstructure:"password"`
+	Store     string            `mapstructure:"store"`
+	Interval  int               `mapstructure:"interval"`
+	Notifiers []notifier.Config `mapstructure:"notifiers"`
+}
+
+var (
+	accountNameRegex = regexp.MustCompile(`^[\w.-]+$`)
+
+	scraperPasswordOpts = struct {
+		delete bool
+	}{}
+	scraperPasswordCmd = &cobra.Command{
+		Use:   "password <account>",
+		Short: "Stores the password of an account of scraper.accounts in the credential store",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if !accountNameRegex.MatchString(name) {
+				return fmt.Errorf("invalid account name %q, use letters, digits, '.', '_' or '-'", name)
+			}
+
+			if scraperPasswordOpts.delete {
+				return secretStore.Delete(accountPasswordKey(name))
+			}
+
+			password, err := promptPassword(fmt.Sprintf("Enter the einet AAI password of account %s: ", name))
+			if err != nil {
+				return err
+			}
+			if password == "" {
+				return errors.New("empty password")
+			}
+
+			return storeAccountPassword(name, password)
+		},
+	}
+)
+
+func init() {
+	scraperPasswordCmd.Flags().BoolVar(&scraperPasswordOpts.delete, "delete", false, "Remove the stored password instead")
+	scraperCmd.AddCommand(scraperPasswordCmd)
+}
+
+func scraperAccounts() ([]scraperAccount, error) {
+	var accounts []scraperAccount
+	if err := defaultViper.UnmarshalKey(ScraperAccountsViperKey.Key(), &accounts); err != nil {
+		return nil, fmt.Errorf("invalid %s configuration: %w", ScraperAccountsViperKey.Key(), err)
+	}
+
+	names := make(map[string]bool)
+	for i, account := range accounts {
+		switch {
+		case !accountNameRegex.MatchString(account.Name):
+			return nil, fmt.Errorf("account %d: name must only contain letters, digits, '.', '_' or '-'", i+1)
+		case names[account.Name]:
+			return nil, fmt.Errorf("account %s is configured twice", account.Name)
+		case account.Username == "":
+			return nil, fmt.Errorf("account %s: username is required", account.Name)
+		}
+
+		names[account.Name] = true
+	}
+
+	if err := migrateAccountPasswords(accounts); err != nil {
+		return nil, err
+	}
+
+	for i := range accounts {
+		if accounts[i].Password != "" {
+			continue
+		}
+
+		password, err := secretStore.Get(accountPasswordKey(accounts[i].Name))
+		if errors.Is(err, credentials.ErrNotFound) {
+			return nil, fmt.Errorf("account %s: no password stored, set it with 'gaps-cli scraper password %s'", accounts[i].Name, accounts[i].Name)
+		} else if err != nil {
+			return nil, fmt.Errorf("account %s: %w", accounts[i].Name, err)
+		}
+
+		accounts[i].Password = password
+	}
+
+	return accounts, nil
+}
+
+// accountPasswordKey is the key of the password of an account in the credential store.
+func accountPasswordKey(name string) string {
+	return fmt.Sprintf("%s.%s.password", ScraperAccountsViperKey.Key(), name)
+}
+
+// accountPasswordKeys are the keys of the passwords of the configured accounts in the credential store, the
+// accounts being listed in v even when their configuration is otherwise invalid.
+func accountPasswordKeys(v *viper.Viper) []string {
+	var accounts []scraperAccount
+	v.UnmarshalKey(ScraperAccountsViperKey.Key(), &accounts)
+
+	keys := make([]string, 0, len(accounts))
+	for _, account := range accounts {
+		if accountNameRegex.MatchString(account.Name) {
+			keys = append(keys, accountPasswordKey(account.Name))
+		}
+	}
+
+	return keys
+}
+
+// storeAccountPassword saves the password of an account, remembering the backend chosen automatically like
+// writeCredentials does.
+func storeAccountPassword(name string, password string) error {
+	if err := secretStore.Set(accountPasswordKey(name), password); err != nil {
+		return fmt.Errorf("could not save the password of account %s to the %s credential store: %w", name, secretStore.Backend(), err)
+	}
+
+	rememberCredentialBackend()
+	return nil
+}
+
+// migrateAccountPasswords moves the passwords written in the configuration to the credential store, then removes
+// them from the configuration file.
+func migrateAccountPasswords(accounts []scraperAccount) error {
+	// replayed sessions must never overwrite the real credentials
+	if replayDir != "" {
+		return nil
+	}
+
+	migrated := false
+	for _, account := range accounts {
+		if account.Password == "" {
+			continue
+		}
+
+		if err := storeAccountPassword(account.Name, account.Password); err != nil {
+			return err
+		}
+
+		log.WithField("account", account.Name).Infof("Moved the account password from the configuration to the %s credential store", secretStore.Backend())
+		migrated = true
+	}
+
+	if !migrated {
+		return nil
+	}
+
+	entries, _ := defaultViper.Get(ScraperAccountsViperKey.Key()).([]any)
+	for _, entry := range entries {
+		if settings, ok := entry.(map[string]any); ok {
+			delete(settings, "password")
+		}
+	}
+	defaultViper.Set(ScraperAccountsViperKey.Key(), entries)
+
+	if err := defaultViper.WriteConfig(); err != nil {
+		return fmt.Errorf("could not remove the account passwords from the configuration: %w", err)
+	}
+
+	return nil
+}
+
+// runAccounts scrapes every account concurrently, each with its own GAPS session, history and back-off state.
+// The accounts only share the rate limit towards GAPS, a failing account doesn't affect the others.
+func runAccounts(ctx context.Context, accounts []scraperAccount) error {
+	global, err := buildNotifiers()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(accounts))
+	interval := scraperOpts.interval
+	for i, account := range accounts {
+		names[i] = account.Name
+		if account.Interval > interval {
+			interval = account.Interval
+		}
+	}
+	health := startMetricsServer(ctx, interval, names...)
+
+	var scrapers []*ScraperCommand
+	for _, account := range accounts {
+		s, err := newAccountScraper(account, global, health)
+		if err != nil {
+			return fmt.Errorf("account %s: %w", account.Name, err)
+		}
+
+		scrapers = append(scrapers, s)
+	}
+
+	var wg sync.WaitGroup
+	for _, s := range scrapers {
+		wg.Add(1)
+		go func(s *ScraperCommand) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					s.log.Errorf("Scraper crashed, the account won't be scraped anymore: %v", r)
+				}
+			}()
+
+			s.loop(ctx)
+		}(s)
+	}
+
+	log.Infof("Scraping %d accounts", len(scrapers))
+	wg.Wait()
+	return nil
+}
+
+func newAccountScraper(account scraperAccount, global notifier.Multi, health *metrics.Health) (*ScraperCommand, error) {
+	s := *scraperOpts
+	s.account = account.Name
+	s.health = health
+	s.log = log.WithField("account", account.Name)
+	// the legacy histories belong to the single account mode
+	s.historyFile = ""
+	s.absencesHistoryFile = ""
+	s.reportCardHistoryFile = ""
+	s.scheduleHistoryFile = ""
+	s.storeFile = account.Store
+	if s.storeFile == "" {
+		s.storeFile = fmt.Sprintf("%s/history-%s.db", getProfileDirectory(), account.Name)
+	}
+	if account.Interval > 0 {
+		s.interval = account.Interval
+	}
+
+	s.notifiers = global
+	if len(account.Notifiers) > 0 {
+		notifiers, err := notifier.NewMulti(account.Notifiers)
+		if err != nil {
+			return nil, err
+		}
+		s.notifiers = notifiers
+	}
+	s.notifiers = s.observe(s.notifiers)
+	if len(s.notifiers) == 0 {
+		s.log.Warn("No notifier configured, changes will only be logged")
+	}
+
+	st, err := store.Open(s.storeFile)
+	if err != nil {
+		return nil, err
+	}
+	st.Close()
+
+	var cfg *gaps.TokenClientConfiguration
+	s.connect = func(ctx context.Context, relogin bool) (*gaps.TokenClientConfiguration, error) {
+		if cfg != nil && !relogin {
+			return cfg, nil
+		}
+
+		s.log.Info("Logging in")
+		login := gaps.NewLoginAction(newClientConfiguration(), account.Username, account.Password)
+		token, err := login.FetchTokenContext(ctx)
+		metrics.Logins.WithLabelValues(s.accountLabel(), metrics.Result(err)).Inc()
+		if err != nil {
+			return nil, err
+		}
+
+		studentId, err := login.FetchStudentIdContext(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+
+		tc := new(gaps.TokenClientConfiguration)
+		if err := tc.InitToken(defaultViper.GetString(UrlViperKey.Key()), token, studentId); err != nil {
+			return nil, err
+		}
+		if transport != nil {
+			tc.SetTransport(transport)
+		}
+		tc.SetCredentialProvider(gaps.StaticCredentials{Username: account.Username, Password: account.Password})
+		tc.OnTokenRefresh(func(string) {
+			metrics.Logins.WithLabelValues(s.accountLabel(), metrics.Result(nil)).Inc()
+		})
+
+		cfg = tc
+		return cfg, nil
+	}
+
+	return &s, nil
+}
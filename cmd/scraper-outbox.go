@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"lutonite.dev/gaps-cli/notifier"
+	"lutonite.dev/gaps-cli/store"
+)
+
+const (
+	// outboxMaxAttempts is the number of failed deliveries after which a notification becomes a dead letter
+	outboxMaxAttempts = 8
+	outboxBaseDelay   = time.Minute
+	outboxMaxDelay    = time.Hour
+)
+
+// errNotifierRemoved marks deliveries to a notifier that is no longer configured.
+var errNotifierRemoved = errors.New("notifier is no longer configured")
+
+// deliver pushes the due notifications of the outbox to their notifier, retrying failed ones with an exponential
+// backoff until they become dead letters.
+func (s *ScraperCommand) deliver(ctx context.Context) {
+	due, err := s.store.Due(time.Now())
+	if err != nil {
+		s.log.WithError(err).Error("Failed to read the notification outbox")
+		return
+	}
+
+	notifiers := make(map[string]notifier.Notifier)
+	for _, n := range s.notifiers {
+		notifiers[n.Name()] = n
+	}
+
+	for _, d := range due {
+		if ctx.Err() != nil {
+			return
+		}
+
+		entry := s.log.WithFields(log.Fields{
+			"notifier": d.Notifier,
+			"type":     d.Event.Type,
+			"attempt":  d.Attempts + 1,
+		})
+
+		n, ok := notifiers[d.Notifier]
+		if !ok {
+			entry.Warn("Notifier is no longer configured, moving notification to dead letters")
+			if err := s.store.Failed(d, errNotifierRemoved, time.Time{}); err != nil {
+				entry.WithError(err).Error("Failed to update the notification outbox")
+			}
+			continue
+		}
+
+		err := n.Notify(ctx, d.Event)
+		if err == nil {
+			entry.Debug("Delivered notification")
+			err = s.store.Delivered(d.Id)
+		} else if errors.Is(err, context.Canceled) {
+			return
+		} else {
+			next := nextDeliveryAttempt(d)
+			if next.IsZero() {
+				entry.WithError(err).Error("Failed to send notification, giving up and moving it to dead letters")
+			} else {
+				entry.WithError(err).Warnf("Failed to send notification, retrying after %s", next.Format(time.TimeOnly))
+			}
+			err = s.store.Failed(d, err, next)
+		}
+
+		if err != nil {
+			entry.WithError(err).Error("Failed to update the notification outbox")
+		}
+	}
+}
+
+// nextDeliveryAttempt returns when a failed delivery should be attempted again, or the zero time once it failed
+// too many times.
+func nextDeliveryAttempt(d *store.Delivery) time.Time {
+	if d.Attempts+1 >= outboxMaxAttempts {
+		return time.Time{}
+	}
+
+	delay := outboxBaseDelay << d.Attempts
+	if delay > outboxMaxDelay {
+		delay = outboxMaxDelay
+	}
+
+	return time.Now().Add(delay)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"lutonite.dev/gaps-cli/store"
+)
+
+func TestNextDeliveryAttempt(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, 2 * time.Minute},
+		{3, 8 * time.Minute},
+		// the delay is capped
+		{6, outboxMaxDelay},
+		// the last attempt gives up
+		{outboxMaxAttempts - 1, 0},
+		{outboxMaxAttempts, 0},
+	}
+
+	for _, tt := range tests {
+		before := time.Now()
+		next := nextDeliveryAttempt(&store.Delivery{Attempts: tt.attempts})
+
+		if tt.want == 0 {
+			if !next.IsZero() {
+				t.Errorf("attempts %d: next attempt at %s, want to give up", tt.attempts, next)
+			}
+			continue
+		}
+
+		if delay := next.Sub(before); delay < tt.want || delay > tt.want+time.Second {
+			t.Errorf("attempts %d: next attempt after %s, want %s", tt.attempts, delay, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/notifier"
+	"lutonite.dev/gaps-cli/parser"
+	"lutonite.dev/gaps-cli/store"
+)
+
+func (s *ScraperCommand) scrapeReportCard(ctx context.Context, cfg *gaps.TokenClientConfiguration) error {
+	modules, err := gaps.NewReportCardAction(cfg).FetchReportCardContext(ctx)
+	if err != nil {
+		s.log.Error("Failed to fetch report card")
+		return err
+	}
+
+	var previous []*parser.ModuleReport
+	found, err := s.store.Latest(store.ReportCard, &previous)
+	if !found {
+		if err != nil {
+			s.log.Error("Failed to read previous report card")
+			return err
+		}
+
+		s.log.Info("No previous report card found, overwriting")
+		return s.commit(store.ReportCard, modules)
+	}
+
+	events := diffReportCard(previous, modules)
+	if len(events) == 0 {
+		s.log.Info("No report card changes found")
+	}
+
+	for _, event := range events {
+		m := event.Module
+		s.log.WithFields(log.Fields{
+			"reason":    m.Reason,
+			"situation": m.Situation,
+			"credits":   m.Credits,
+		}).Infof("MODULE [%s] %s", m.Module, m.Reason)
+		s.enqueue(event)
+	}
+
+	return s.commit(store.ReportCard, modules)
+}
+
+// diffReportCard compares two report cards module by module, a module being identified by its code and year
+// since failed modules are listed again when they are retaken.
+func diffReportCard(previous []*parser.ModuleReport, current []*parser.ModuleReport) []*notifier.Event {
+	key := func(m *parser.ModuleReport) string {
+		return fmt.Sprintf("%s/%d", m.Identifier, m.Year)
+	}
+
+	previousModules := make(map[string]*parser.ModuleReport)
+	for _, m := range previous {
+		previousModules[key(m)] = m
+	}
+
+	var events []*notifier.Event
+	now := time.Now()
+	for _, module := range current {
+		before, ok := previousModules[key(module)]
+		if !ok {
+			before = &parser.ModuleReport{Situation: module.Situation}
+		}
+
+		emit := func(reason notifier.ModuleReason) {
+			events = append(events, &notifier.Event{
+				Type: notifier.ModuleEvent,
+				Time: now,
+				Module: &notifier.ModuleChange{
+					Reason:            reason,
+					Module:            module.Identifier,
+					Name:              module.Name,
+					Year:              module.Year,
+					PassingGrade:      module.PassingGrade,
+					Grade:             module.GlobalGrade,
+					PreviousGrade:     before.GlobalGrade,
+					Situation:         strings.TrimSpace(module.Situation),
+					PreviousSituation: strings.TrimSpace(before.Situation),
+					Credits:           module.Credits,
+					PreviousCredits:   before.Credits,
+				},
+			})
+		}
+
+		if module.GlobalGradeValue.IsGraded() && module.GlobalGrade != before.GlobalGrade {
+			emit(notifier.ModuleGradePublished)
+		}
+		if strings.TrimSpace(module.Situation) != strings.TrimSpace(before.Situation) {
+			emit(notifier.ModuleSituationChanged)
+		}
+		if module.Credits > before.Credits {
+			emit(notifier.ModuleCreditsGranted)
+		}
+	}
+
+	return events
+}
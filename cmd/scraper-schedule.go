@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/notifier"
+	"lutonite.dev/gaps-cli/store"
+)
+
+func (s *ScraperCommand) scrapeSchedule(ctx context.Context, cfg *gaps.TokenClientConfiguration, year uint) error {
+	calendars, err := fetchScheduleTerms(ctx, s.log, cfg, year, gaps.All.ScheduleTerms(), gaps.StudentSchedule, 0)
+	if err != nil {
+		s.log.Error("Failed to fetch schedule")
+		return err
+	}
+
+	lessons := gaps.Lessons(gaps.MergeCalendars(calendars...))
+
+	var previous []*gaps.Lesson
+	found, err := s.store.Latest(store.Schedule, &previous)
+	if !found {
+		if err != nil {
+			s.log.Error("Failed to read previous schedule")
+			return err
+		}
+
+		s.log.Info("No previous schedule found, overwriting")
+		return s.commit(store.Schedule, lessons)
+	}
+
+	now := time.Now()
+	events := diffSchedule(previous, lessons, now, now.AddDate(0, 0, s.scheduleLookahead))
+	if len(events) == 0 {
+		s.log.Info("No schedule changes found")
+	}
+
+	for _, event := range events {
+		l := event.Lesson
+		s.log.WithFields(log.Fields{
+			"start":    l.Start,
+			"location": l.Location,
+		}).Infof("LESSON [%s] %s", l.Summary, l.Reason)
+		s.enqueue(event)
+	}
+
+	return s.commit(store.Schedule, lessons)
+}
+
+// diffSchedule compares two snapshots of the schedule by event UID, only reporting the lessons that were or are
+// taking place in [from, to) so changes far in the future or in the past don't cause noise.
+func diffSchedule(previous []*gaps.Lesson, current []*gaps.Lesson, from time.Time, to time.Time) []*notifier.Event {
+	key := func(l *gaps.Lesson) string {
+		if l.Uid != "" {
+			return l.Uid
+		}
+
+		return fmt.Sprintf("%s@%s", l.Summary, l.Start.Format(time.RFC3339))
+	}
+	inWindow := func(t time.Time) bool {
+		return !t.Before(from) && t.Before(to)
+	}
+
+	previousLessons := make(map[string]*gaps.Lesson)
+	for _, l := range previous {
+		previousLessons[key(l)] = l
+	}
+
+	var events []*notifier.Event
+	emit := func(reason notifier.LessonReason, lesson *gaps.Lesson, before *gaps.Lesson) {
+		change := &notifier.LessonChange{
+			Reason:   reason,
+			Uid:      lesson.Uid,
+			Summary:  lesson.Summary,
+			Location: lesson.Location,
+			Start:    lesson.Start,
+			End:      lesson.End,
+		}
+		if before != nil {
+			change.PreviousLocation = before.Location
+			change.PreviousStart = &before.Start
+			change.PreviousEnd = &before.End
+		}
+
+		events = append(events, &notifier.Event{Type: notifier.LessonEvent, Time: time.Now(), Lesson: change})
+	}
+
+	for _, lesson := range current {
+		k := key(lesson)
+		before, ok := previousLessons[k]
+		delete(previousLessons, k)
+
+		if !ok {
+			if inWindow(lesson.Start) {
+				emit(notifier.LessonAdded, lesson, nil)
+			}
+			continue
+		}
+
+		if !inWindow(lesson.Start) && !inWindow(before.Start) {
+			continue
+		}
+
+		if !lesson.Start.Equal(before.Start) || !lesson.End.Equal(before.End) {
+			emit(notifier.LessonRescheduled, lesson, before)
+		}
+		if lesson.Location != before.Location {
+			emit(notifier.LessonRelocated, lesson, before)
+		}
+	}
+
+	var removed []*gaps.Lesson
+	for _, lesson := range previousLessons {
+		if inWindow(lesson.Start) {
+			removed = append(removed, lesson)
+		}
+	}
+
+	sort.Slice(removed, func(i, j int) bool {
+		return removed[i].Start.Before(removed[j].Start)
+	})
+	for _, lesson := range removed {
+		emit(notifier.LessonRemoved, lesson, nil)
+	}
+
+	return events
+}
@@ -2,20 +2,19 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/r3labs/diff/v3"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/metrics"
 	"lutonite.dev/gaps-cli/notifier"
 	"lutonite.dev/gaps-cli/parser"
-	"os"
-	"os/signal"
+	"lutonite.dev/gaps-cli/store"
+	"net/http"
 	"regexp"
-	"strconv"
 	"strings"
-	"syscall"
 	"time"
 )
 
@@ -23,9 +22,38 @@ type ScraperCommand struct {
 	apiUrl string
 	apiKey string
 
-	interval int
-
-	historyFile string
+	interval  int
+	rateLimit float64
+
+	// the history files are the JSON histories of previous versions, migrated to the store on startup
+	historyFile           string
+	absencesHistoryFile   string
+	reportCardHistoryFile string
+	scheduleHistoryFile   string
+	storeFile             string
+	store                 *store.Store
+
+	absences          bool
+	reportCard        bool
+	schedule          bool
+	scheduleLookahead int
+
+	notifiers notifier.Multi
+	// pending holds the events of the current scrape until they are committed along with its snapshot
+	pending []*notifier.Event
+
+	// account is the name of the scraped account in multi-account mode, empty otherwise
+	account string
+	log     *log.Entry
+	// connect returns a client logged in to GAPS, logging in again when relogin is set
+	connect func(ctx context.Context, relogin bool) (*gaps.TokenClientConfiguration, error)
+
+	metricsListen string
+	readyMaxAge   int
+	health        *metrics.Health
+
+	failures int
+	retryAt  time.Time
 }
 
 type scraperResult map[string]map[string]*scraperGrade
@@ -37,39 +65,79 @@ type scraperGrade struct {
 	Weight      float32   `json:"weight" diff:"-"`
 	Grade       string    `json:"grade"`
 	ClassMean   string    `json:"classMean"`
+	// ClassMeanValue is the parsed ClassMean, changes are already detected on the latter
+	ClassMeanValue parser.GradeValue `json:"classMeanValue" diff:"-"`
 }
 
 var (
 	scraperOpts = &ScraperCommand{}
 	scraperCmd  = &cobra.Command{
 		Use:   "scraper",
-		Short: "Runs a scraper for grades for the distributed Discord notifications API",
+		Short: "Runs a scraper for grades, pushing changes to the configured notifiers",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			log.Info("Refreshing token")
-			refreshToken(
-				defaultViper.GetString(UsernameViperKey.Key()),
-				credentialsViper.GetString(PasswordViperKey.Key()),
-			)
-
-			log.Info("Starting scraper thread")
-
-			c := make(chan os.Signal)
-			signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-
-			ticker := time.NewTicker(time.Duration(scraperOpts.interval) * time.Second)
-
-			defer ticker.Stop()
-			for {
-				select {
-				case <-c:
-					log.Info("Received interrupt, exiting")
-					return nil
-				case <-ticker.C:
-					if err := scraperOpts.runScraper(); err != nil {
-						log.WithError(err).Error("Failed to run scraper")
+			ctx := cmd.Context()
+			transport = metrics.InstrumentTransport(transport)
+			if scraperOpts.rateLimit > 0 {
+				transport = gaps.NewRateLimitedTransport(transport, scraperOpts.rateLimit, 5)
+			}
+
+			accounts, err := scraperAccounts()
+			if err != nil {
+				return err
+			}
+			if len(accounts) > 0 {
+				return runAccounts(ctx, accounts)
+			}
+
+			scraperOpts.log = log.NewEntry(log.StandardLogger())
+			scraperOpts.health = startMetricsServer(ctx, scraperOpts.interval, scraperOpts.accountLabel())
+
+			notifiers, err := buildNotifiers()
+			if err != nil {
+				return err
+			}
+			if len(notifiers) == 0 {
+				log.Warn("No notifier configured, changes will only be logged")
+			}
+			scraperOpts.notifiers = scraperOpts.observe(notifiers)
+
+			st, err := openStore(scraperOpts.storeFile, scraperOpts.legacyHistoryFiles())
+			if err != nil {
+				return err
+			}
+			// the database is only held during runs, so other commands can read it in the meantime
+			st.Close()
+
+			scraperOpts.connect = func(ctx context.Context, relogin bool) (*gaps.TokenClientConfiguration, error) {
+				if relogin || credentialsViper.GetString(TokenValueViperKey.Key()) == "" {
+					err := refreshToken(
+						ctx,
+						defaultViper.GetString(UsernameViperKey.Key()),
+						credentialsViper.GetString(PasswordViperKey.Key()),
+					)
+					metrics.Logins.WithLabelValues(scraperOpts.accountLabel(), metrics.Result(err)).Inc()
+					if err != nil {
+						return nil, err
 					}
 				}
+
+				return buildTokenClientConfiguration(ctx, func(token string) {
+					persistToken(token)
+					metrics.Logins.WithLabelValues(scraperOpts.accountLabel(), metrics.Result(nil)).Inc()
+				})
+			}
+
+			log.Info("Refreshing token")
+			if _, err := scraperOpts.connect(ctx, true); err != nil {
+				if errors.Is(err, gaps.ErrInvalidCredentials) {
+					return err
+				}
+				// GAPS being down must not prevent the daemon from starting, the next runs log in again
+				scraperOpts.handleError(ctx, err)
 			}
+
+			scraperOpts.loop(ctx)
+			return nil
 		},
 	}
 )
@@ -81,9 +149,20 @@ func init() {
 	scraperCmd.Flags().StringP(PasswordViperKey.Flag(), "p", "", "einet aai password")
 	credentialsViper.BindPFlag(PasswordViperKey.Key(), scraperCmd.Flags().Lookup(PasswordViperKey.Flag()))
 
-	scraperCmd.Flags().StringVar(&scraperOpts.historyFile, GradesHistoryFileViperKey.Flag(), "", "history file (default is $HOME/.config/gaps-cli/grades-history.json)")
+	scraperCmd.Flags().StringVar(&scraperOpts.storeFile, HistoryStoreFileViperKey.Flag(), "", "history database (default is history.db in the profile directory)")
+	defaultViper.BindPFlag(HistoryStoreFileViperKey.Key(), scraperCmd.Flags().Lookup(HistoryStoreFileViperKey.Flag()))
+
+	scraperCmd.Flags().StringVar(&scraperOpts.historyFile, GradesHistoryFileViperKey.Flag(), "", "legacy JSON grades history to import in the history database (default is grades-history.json in the profile directory)")
 	defaultViper.BindPFlag(GradesHistoryFileViperKey.Key(), scraperCmd.Flags().Lookup(GradesHistoryFileViperKey.Flag()))
-	defaultViper.SetDefault(GradesHistoryFileViperKey.Key(), getConfigDirectory()+"/gaps-cli/grades-history.json")
+
+	scraperCmd.Flags().StringVar(&scraperOpts.absencesHistoryFile, AbsencesHistoryFileViperKey.Flag(), "", "legacy JSON absences history to import in the history database (default is absences-history.json in the profile directory)")
+	defaultViper.BindPFlag(AbsencesHistoryFileViperKey.Key(), scraperCmd.Flags().Lookup(AbsencesHistoryFileViperKey.Flag()))
+
+	scraperCmd.Flags().StringVar(&scraperOpts.reportCardHistoryFile, ReportCardHistoryFileViperKey.Flag(), "", "legacy JSON report card history to import in the history database (default is report-card-history.json in the profile directory)")
+	defaultViper.BindPFlag(ReportCardHistoryFileViperKey.Key(), scraperCmd.Flags().Lookup(ReportCardHistoryFileViperKey.Flag()))
+
+	scraperCmd.Flags().StringVar(&scraperOpts.scheduleHistoryFile, ScheduleHistoryFileViperKey.Flag(), "", "legacy JSON schedule history to import in the history database (default is schedule-history.json in the profile directory)")
+	defaultViper.BindPFlag(ScheduleHistoryFileViperKey.Key(), scraperCmd.Flags().Lookup(ScheduleHistoryFileViperKey.Flag()))
 
 	scraperCmd.Flags().StringVarP(&scraperOpts.apiUrl, ScraperApiUrlViperKey.Flag(), "U", "", "Notifier API URL")
 	defaultViper.BindPFlag(ScraperApiUrlViperKey.Key(), scraperCmd.Flags().Lookup(ScraperApiUrlViperKey.Flag()))
@@ -91,21 +170,113 @@ func init() {
 	scraperCmd.Flags().StringVarP(&scraperOpts.apiKey, ScraperApiKeyViperKey.Flag(), "k", "", "Notifier API key")
 	defaultViper.BindPFlag(ScraperApiKeyViperKey.Key(), scraperCmd.Flags().Lookup(ScraperApiKeyViperKey.Flag()))
 
+	scraperCmd.Flags().BoolVar(&scraperOpts.absences, ScraperAbsencesViperKey.Flag(), false, "Also watch absences")
+	defaultViper.BindPFlag(ScraperAbsencesViperKey.Key(), scraperCmd.Flags().Lookup(ScraperAbsencesViperKey.Flag()))
+
+	scraperCmd.Flags().BoolVar(&scraperOpts.reportCard, ScraperReportCardViperKey.Flag(), false, "Also watch the report card")
+	defaultViper.BindPFlag(ScraperReportCardViperKey.Key(), scraperCmd.Flags().Lookup(ScraperReportCardViperKey.Flag()))
+
+	scraperCmd.Flags().BoolVar(&scraperOpts.schedule, ScraperScheduleViperKey.Flag(), false, "Also watch the schedule")
+	defaultViper.BindPFlag(ScraperScheduleViperKey.Key(), scraperCmd.Flags().Lookup(ScraperScheduleViperKey.Flag()))
+
+	scraperCmd.Flags().IntVar(&scraperOpts.scheduleLookahead, ScraperScheduleLookaheadViperKey.Flag(), 14, "Number of days ahead to notify schedule changes for")
+	defaultViper.BindPFlag(ScraperScheduleLookaheadViperKey.Key(), scraperCmd.Flags().Lookup(ScraperScheduleLookaheadViperKey.Flag()))
+
+	scraperCmd.Flags().IntSlice(AbsenceRelativeThresholdsViperKey.Flag(), []int{8, 15}, "Relative absence rates (in %) to notify about")
+	defaultViper.BindPFlag(AbsenceRelativeThresholdsViperKey.Key(), scraperCmd.Flags().Lookup(AbsenceRelativeThresholdsViperKey.Flag()))
+
+	scraperCmd.Flags().IntSlice(AbsenceAbsoluteThresholdsViperKey.Flag(), []int{8, 15}, "Absolute absence rates (in %) to notify about")
+	defaultViper.BindPFlag(AbsenceAbsoluteThresholdsViperKey.Key(), scraperCmd.Flags().Lookup(AbsenceAbsoluteThresholdsViperKey.Flag()))
+
 	scraperCmd.Flags().IntVar(&scraperOpts.interval, "interval", 300, "Interval between each scrape (in seconds)")
 
+	scraperCmd.Flags().StringVar(&scraperOpts.metricsListen, ScraperMetricsListenViperKey.Flag(), "", "Address to serve /healthz, /readyz and /metrics on (default is disabled)")
+	defaultViper.BindPFlag(ScraperMetricsListenViperKey.Key(), scraperCmd.Flags().Lookup(ScraperMetricsListenViperKey.Flag()))
+
+	scraperCmd.Flags().IntVar(&scraperOpts.readyMaxAge, "ready-max-age", 0, "Maximum age of the last successful scrape for /readyz (in seconds, default is 3 intervals and a minute)")
+
+	scraperCmd.Flags().Float64Var(&scraperOpts.rateLimit, ScraperRateLimitViperKey.Flag(), 2, "Maximum requests per second to GAPS, shared by all accounts (0 disables the limit)")
+	defaultViper.BindPFlag(ScraperRateLimitViperKey.Key(), scraperCmd.Flags().Lookup(ScraperRateLimitViperKey.Flag()))
+
 	rootCmd.AddCommand(scraperCmd)
 }
 
-func (s *ScraperCommand) runScraper() error {
-	cfg := buildTokenClientConfiguration()
+// loop runs the scraper on every interval until the context is done.
+func (s *ScraperCommand) loop(ctx context.Context) {
+	s.log.Info("Starting scraper thread")
+
+	ticker := time.NewTicker(time.Duration(s.interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.log.Info("Received interrupt, exiting")
+			return
+		case <-ticker.C:
+			if time.Now().Before(s.retryAt) {
+				s.log.Debugf("Backing off until %s", s.retryAt.Format(time.TimeOnly))
+				continue
+			}
+
+			start := time.Now()
+			err := s.runScraper(ctx)
+			metrics.ScrapeDuration.WithLabelValues(s.accountLabel(), metrics.Result(err)).Observe(time.Since(start).Seconds())
+			if err != nil {
+				s.handleError(ctx, err)
+				continue
+			}
+
+			s.failures = 0
+			s.health.Success(s.accountLabel())
+		}
+	}
+}
+
+func (s *ScraperCommand) runScraper(ctx context.Context) error {
+	st, err := store.Open(s.storeFile)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	s.store = st
+
+	// notifications are retried even when GAPS is unreachable
+	defer s.deliver(ctx)
 
+	cfg, err := s.connect(ctx, false)
+	if err != nil {
+		return err
+	}
 	year := currentAcademicYear()
-	classes := gaps.GetAllClasses(cfg, year)
+
+	err = s.scrapeGrades(ctx, cfg, year)
+	if s.absences {
+		err = errors.Join(err, s.scrapeAbsences(ctx, cfg, year))
+	}
+	if s.reportCard {
+		err = errors.Join(err, s.scrapeReportCard(ctx, cfg))
+	}
+	if s.schedule {
+		// GAPS failing to serve the schedule must not back off the grades, only an expired session matters
+		scheduleErr := s.scrapeSchedule(ctx, cfg, year)
+		if errors.Is(scheduleErr, gaps.ErrSessionExpired) {
+			err = errors.Join(err, scheduleErr)
+		} else if scheduleErr != nil && !errors.Is(scheduleErr, context.Canceled) {
+			s.log.WithError(scheduleErr).Error("Failed to scrape the schedule")
+		}
+	}
+
+	return err
+}
+
+func (s *ScraperCommand) scrapeGrades(ctx context.Context, cfg *gaps.TokenClientConfiguration, year uint) error {
+	classes := gaps.GetAllClassesContext(ctx, cfg, year)
 
 	ga := gaps.NewGradesAction(cfg, year)
-	g, err := ga.FetchGrades()
+	g, err := ga.FetchGradesContext(ctx)
 	if err != nil {
-		log.Error("Failed to fetch grades")
+		s.log.Error("Failed to fetch grades")
 		return err
 	}
 
@@ -113,11 +284,11 @@ func (s *ScraperCommand) runScraper() error {
 	previousGrades, err := s.readHistory()
 	if previousGrades == nil {
 		if err != nil {
-			log.Error("Failed to read previous grades")
+			s.log.Error("Failed to read previous grades")
 			return err
 		}
 
-		log.Info("No previous grades found, overwriting")
+		s.log.Info("No previous grades found, overwriting")
 		return s.writeHistory(grades)
 	}
 
@@ -128,45 +299,127 @@ func (s *ScraperCommand) runScraper() error {
 	)
 
 	notifications := make(map[scraperGrade]bool)
-	client := notifier.NewClient(s.apiUrl, s.apiKey)
 
 	if len(diff) == 0 {
-		log.Info("No changes found")
+		s.log.Info("No changes found")
 		return nil
 	}
 
 	for _, change := range diff {
 		grade := s.resolveGrade(grades, change)
 		previous := s.resolveGrade(previousGrades, change)
-		if grade == nil || grade.ClassMean == "-" {
+		if grade == nil {
 			continue
 		}
 
-		if notifications[*grade] {
+		if !grade.ClassMeanValue.IsGraded() {
 			continue
 		}
-		notifications[*grade] = true
 
-		nmean, _ := strconv.ParseFloat(grade.ClassMean, 32)
-		n := &notifier.ApiGrade{
-			Course: grade.Course,
-			Class:  s.findClass(grade, classes),
-			Name:   grade.Description,
-			Mean:   float32(nmean),
+		if notifications[*grade] {
+			continue
 		}
+		notifications[*grade] = true
 
 		s.logChange(previous, grade, change)
 
-		ctx := context.Background()
-		err = client.SendGrade(ctx, n)
-		if err != nil {
-			log.WithError(err).Error("Failed to send grade")
-		}
+		s.enqueue(s.gradeEvent(previous, grade, classes))
 	}
 
 	return s.writeHistory(grades)
 }
 
+// handleError reacts to a failed scraper run depending on what went wrong on the GAPS side.
+func (s *ScraperCommand) handleError(ctx context.Context, err error) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return
+	case errors.Is(err, gaps.ErrSessionExpired):
+		s.log.WithError(err).Warn("GAPS session expired, logging in again")
+		if _, err := s.connect(ctx, true); err != nil && !errors.Is(err, gaps.ErrSessionExpired) {
+			s.log.WithError(err).Error("Failed to log in again")
+			s.handleError(ctx, err)
+		}
+	case errors.Is(err, gaps.ErrInvalidCredentials):
+		// retrying right away could get the account locked, wait as long as for a failing server
+		s.failures = 6
+		s.backOff()
+		s.log.WithError(err).Errorf("Invalid credentials, pausing until %s", s.retryAt.Format(time.DateTime))
+	case gaps.IsServerError(err):
+		if s.failures < 6 {
+			s.failures++
+		}
+		delay := s.backOff()
+		s.log.WithError(err).Warnf("GAPS server error, backing off for %s", delay)
+	case errors.Is(err, gaps.ErrUnexpectedStructure):
+		metrics.ParseFailures.WithLabelValues(s.accountLabel()).Inc()
+		s.log.WithError(err).Error("GAPS returned an unexpected page structure, the parser probably needs an update")
+	default:
+		s.log.WithError(err).Error("Failed to run scraper")
+	}
+}
+
+// accountLabel identifies the account in metrics.
+func (s *ScraperCommand) accountLabel() string {
+	if s.account == "" {
+		return "default"
+	}
+
+	return s.account
+}
+
+// observe counts the notifications sent by each notifier of the account.
+func (s *ScraperCommand) observe(notifiers notifier.Multi) notifier.Multi {
+	observed := make(notifier.Multi, len(notifiers))
+	for i, n := range notifiers {
+		observed[i] = notifier.Observe(n, func(name string, event *notifier.Event, err error) {
+			metrics.Notifications.WithLabelValues(s.accountLabel(), name, string(event.Type), metrics.Result(err)).Inc()
+		})
+	}
+
+	return observed
+}
+
+// startMetricsServer serves the health and metrics endpoints when enabled, accounts being considered unhealthy
+// after missing a few runs.
+func startMetricsServer(ctx context.Context, interval int, accounts ...string) *metrics.Health {
+	maxAge := time.Duration(scraperOpts.readyMaxAge) * time.Second
+	if maxAge <= 0 {
+		maxAge = 3*time.Duration(interval)*time.Second + time.Minute
+	}
+
+	health := metrics.NewHealth(maxAge, accounts...)
+	if scraperOpts.metricsListen == "" {
+		return health
+	}
+
+	srv := &http.Server{
+		Addr:              scraperOpts.metricsListen,
+		Handler:           metrics.Handler(health),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	go func() {
+		log.Infof("Serving health and metrics on http://%s", scraperOpts.metricsListen)
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			log.WithError(err).Error("Metrics server failed")
+		}
+	}()
+
+	return health
+}
+
+// backOff delays the next run exponentially with the number of consecutive failures.
+func (s *ScraperCommand) backOff() time.Duration {
+	delay := time.Duration(s.interval) * time.Second * time.Duration(1<<s.failures)
+	s.retryAt = time.Now().Add(delay)
+	return delay
+}
+
 func (s *ScraperCommand) mapGrades(grades []*parser.ClassGrades) scraperResult {
 	scraperGrades := make(scraperResult)
 	for _, class := range grades {
@@ -174,13 +427,14 @@ func (s *ScraperCommand) mapGrades(grades []*parser.ClassGrades) scraperResult {
 		for _, group := range class.GradeGroups {
 			for _, grade := range group.Grades {
 				scraperGrades[class.Name][grade.Description] = &scraperGrade{
-					Course:      class.Name,
-					Type:        group.Name,
-					Description: grade.Description,
-					Date:        grade.Date,
-					Weight:      grade.Weight,
-					Grade:       grade.Grade,
-					ClassMean:   grade.ClassMean,
+					Course:         class.Name,
+					Type:           group.Name,
+					Description:    grade.Description,
+					Date:           grade.Date,
+					Weight:         grade.Weight,
+					Grade:          grade.Grade,
+					ClassMean:      grade.ClassMean,
+					ClassMeanValue: grade.ClassMeanValue,
 				}
 			}
 		}
@@ -199,7 +453,7 @@ func (s *ScraperCommand) resolveGrade(grades scraperResult, change diff.Change)
 
 func (s *ScraperCommand) logChange(previous *scraperGrade, grade *scraperGrade, change diff.Change) {
 	if change.Type == diff.CREATE || previous == nil {
-		log.WithFields(log.Fields{
+		s.log.WithFields(log.Fields{
 			"new-grade":   grade.Grade,
 			"new-average": grade.ClassMean,
 		}).Infof(
@@ -213,7 +467,7 @@ func (s *ScraperCommand) logChange(previous *scraperGrade, grade *scraperGrade,
 		return
 	}
 
-	log.WithFields(log.Fields{
+	s.log.WithFields(log.Fields{
 		"previous-grade":   previous.Grade,
 		"new-grade":        grade.Grade,
 		"previous-average": previous.ClassMean,
@@ -229,28 +483,117 @@ func (s *ScraperCommand) logChange(previous *scraperGrade, grade *scraperGrade,
 	)
 }
 
+func (s *ScraperCommand) gradeEvent(previous *scraperGrade, grade *scraperGrade, classes []string) *notifier.Event {
+	change := &notifier.GradeChange{
+		Course:    grade.Course,
+		Class:     s.findClass(grade, classes),
+		Type:      grade.Type,
+		Name:      grade.Description,
+		Date:      grade.Date,
+		Weight:    grade.Weight,
+		Grade:     grade.Grade,
+		ClassMean: grade.ClassMean,
+		New:       previous == nil,
+	}
+	if previous != nil {
+		change.PreviousGrade = previous.Grade
+		change.PreviousClassMean = previous.ClassMean
+	}
+
+	return &notifier.Event{Type: notifier.GradeEvent, Time: time.Now(), Grade: change}
+}
+
+// buildNotifiers creates the notifiers of the scraper.notifiers list, along with the notifications API when
+// configured through scraper.api.url as it was before notifiers could be configured.
+func buildNotifiers() (notifier.Multi, error) {
+	var configs []notifier.Config
+	if err := defaultViper.UnmarshalKey(ScraperNotifiersViperKey.Key(), &configs); err != nil {
+		return nil, fmt.Errorf("invalid %s configuration: %w", ScraperNotifiersViperKey.Key(), err)
+	}
+
+	if scraperOpts.apiUrl != "" {
+		configs = append([]notifier.Config{{Type: "api", Url: scraperOpts.apiUrl, Token: scraperOpts.apiKey}}, configs...)
+	}
+
+	return notifier.NewMulti(configs)
+}
+
 func (s *ScraperCommand) readHistory() (scraperResult, error) {
 	var grades scraperResult
-	data, err := os.ReadFile(s.historyFile)
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil, nil
-		}
-
+	if found, err := s.store.Latest(store.Grades, &grades); !found {
 		return nil, err
 	}
 
-	err = json.Unmarshal(data, &grades)
-	return grades, err
+	return grades, nil
 }
 
 func (s *ScraperCommand) writeHistory(grades scraperResult) error {
-	data, err := json.MarshalIndent(grades, "", "\t")
+	return s.commit(store.Grades, grades)
+}
+
+// enqueue adds an event to those committed with the next snapshot.
+func (s *ScraperCommand) enqueue(event *notifier.Event) {
+	s.pending = append(s.pending, event)
+}
+
+// commit saves a snapshot along with the pending events, queuing them in the outbox for each notifier.
+func (s *ScraperCommand) commit(kind store.Kind, v any) error {
+	names := make([]string, len(s.notifiers))
+	for i, n := range s.notifiers {
+		names[i] = n.Name()
+	}
+
+	events := s.pending
+	s.pending = nil
+
+	saved, err := s.store.Commit(kind, time.Now(), v, events, names)
 	if err != nil {
+		s.log.WithError(err).Errorf("Failed to store %s snapshot", kind)
 		return err
 	}
 
-	return os.WriteFile(s.historyFile, data, 0644)
+	if saved {
+		s.log.Debugf("Stored new %s snapshot", kind)
+	}
+	if len(events) > 0 {
+		s.log.Debugf("Queued %d %s events", len(events), kind)
+	}
+	return nil
+}
+
+// legacyHistoryFiles returns the JSON history files of previous versions by kind of snapshot.
+func (s *ScraperCommand) legacyHistoryFiles() map[store.Kind]string {
+	return map[store.Kind]string{
+		store.Grades:     s.historyFile,
+		store.Absences:   s.absencesHistoryFile,
+		store.ReportCard: s.reportCardHistoryFile,
+		store.Schedule:   s.scheduleHistoryFile,
+	}
+}
+
+// openStore opens the history database, importing the JSON history files of previous versions into it.
+func openStore(path string, legacy map[store.Kind]string) (*store.Store, error) {
+	st, err := store.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, kind := range store.Kinds {
+		if legacy[kind] == "" {
+			continue
+		}
+
+		imported, err := st.ImportFile(kind, legacy[kind])
+		if err != nil {
+			st.Close()
+			return nil, err
+		}
+		if imported {
+			log.WithField("file", legacy[kind]).Infof("Migrated %s history to %s", kind, path)
+		}
+	}
+
+	return st, nil
 }
 
 func (s *ScraperCommand) findClass(grade *scraperGrade, classes []string) string {
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/gaps/gapstest"
+	"lutonite.dev/gaps-cli/metrics"
+	"lutonite.dev/gaps-cli/notifier"
+	"lutonite.dev/gaps-cli/parser"
+	"lutonite.dev/gaps-cli/store"
+)
+
+// recordingNotifier keeps the events it is notified of.
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []*notifier.Event
+}
+
+func (n *recordingNotifier) Name() string {
+	return "recording"
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, event *notifier.Event) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, event)
+	return nil
+}
+
+func newTestScraper(t *testing.T, fixtures *gapstest.Fixtures) (*ScraperCommand, *gapstest.Server, *recordingNotifier) {
+	t.Helper()
+
+	// the fixtures are served for the year the scraper asks for
+	year := currentAcademicYear()
+	if year != 2023 {
+		fixtures.Grades = map[uint][]*parser.ClassGrades{year: fixtures.Grades[2023]}
+	}
+
+	srv := gapstest.NewServer(fixtures)
+	t.Cleanup(srv.Close)
+
+	previousUrl := defaultViper.GetString(UrlViperKey.Key())
+	defaultViper.Set(UrlViperKey.Key(), srv.URL)
+	t.Cleanup(func() { defaultViper.Set(UrlViperKey.Key(), previousUrl) })
+
+	rec := &recordingNotifier{}
+	account := scraperAccount{
+		Name:     "test",
+		Username: fixtures.Username,
+		Password: fixtures.Password,
+		Store:    t.TempDir() + "/history.db",
+	}
+	s, err := newAccountScraper(account, notifier.Multi{rec}, metrics.NewHealth(time.Minute, account.Name))
+	if err != nil {
+		t.Fatalf("newAccountScraper: %v", err)
+	}
+	s.absences = false
+	s.reportCard = false
+	s.schedule = false
+
+	return s, srv, rec
+}
+
+func TestScraperRun(t *testing.T) {
+	s, srv, rec := newTestScraper(t, gapstest.DefaultFixtures())
+	ctx := context.Background()
+
+	// the first run only stores the grades
+	if err := s.runScraper(ctx); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	if len(rec.events) != 0 {
+		t.Fatalf("first run sent %d events, want none", len(rec.events))
+	}
+
+	if err := s.runScraper(ctx); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if len(rec.events) != 0 {
+		t.Fatalf("unchanged grades sent %d events, want none", len(rec.events))
+	}
+
+	srv.Update(func(fixtures *gapstest.Fixtures) {
+		for _, classes := range fixtures.Grades {
+			group := classes[0].GradeGroups[0]
+			group.Grades = append(group.Grades, &parser.Grade{
+				Description: "TE3",
+				Date:        time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC),
+				Weight:      50,
+				Grade:       "5.5",
+				ClassMean:   "4.4",
+			})
+		}
+	})
+	// the scraper must log in again transparently
+	srv.ExpireSessions()
+
+	if err := s.runScraper(ctx); err != nil {
+		t.Fatalf("third run: %v", err)
+	}
+	if len(rec.events) != 1 {
+		t.Fatalf("new grade sent %d events, want 1", len(rec.events))
+	}
+
+	event := rec.events[0]
+	if event.Type != notifier.GradeEvent || event.Grade == nil {
+		t.Fatalf("event = %+v, want a grade event", event)
+	}
+	if event.Grade.Name != "TE3" || event.Grade.Grade != "5.5" || !event.Grade.New {
+		t.Errorf("grade change = %+v, want new grade TE3 of 5.5", event.Grade)
+	}
+}
+
+func TestScraperRunInvalidCredentials(t *testing.T) {
+	fixtures := gapstest.DefaultFixtures()
+	s, srv, _ := newTestScraper(t, fixtures)
+	srv.Update(func(fixtures *gapstest.Fixtures) {
+		fixtures.Password = "changed"
+	})
+
+	err := s.runScraper(context.Background())
+	if err == nil {
+		t.Fatal("run succeeded with invalid credentials")
+	}
+
+	s.handleError(context.Background(), err)
+	if !s.retryAt.After(time.Now()) {
+		t.Error("invalid credentials didn't pause the scraper")
+	}
+}
+
+func TestScraperRunScheduleUnavailable(t *testing.T) {
+	s, srv, _ := newTestScraper(t, gapstest.DefaultFixtures())
+	s.schedule = true
+
+	// GAPS fails to serve the schedule of some terms
+	failing := map[string]bool{"3": true}
+	proxy := httputil.NewSingleHostReverseProxy(mustParseUrl(t, srv.URL))
+	front := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/consultation/horaires") && failing[r.URL.Query().Get("trimestre")] {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		proxy.ServeHTTP(w, r)
+	}))
+	t.Cleanup(front.Close)
+	defaultViper.Set(UrlViperKey.Key(), front.URL)
+
+	ctx := context.Background()
+	if err := s.runScraper(ctx); err != nil {
+		t.Fatalf("run with an unavailable term: %v", err)
+	}
+
+	st, err := store.OpenReadOnly(s.storeFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lessons []*gaps.Lesson
+	found, err := st.Latest(store.Schedule, &lessons)
+	st.Close()
+	if !found || err != nil {
+		t.Fatalf("schedule not stored despite the available terms: %t, %v", found, err)
+	}
+
+	// even a schedule failing completely doesn't back off the grades
+	failing = map[string]bool{"0": true, "1": true, "2": true, "3": true}
+	if err := s.runScraper(ctx); err != nil {
+		t.Fatalf("run with an unavailable schedule: %v", err)
+	}
+}
+
+func mustParseUrl(t *testing.T, raw string) *url.URL {
+	t.Helper()
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return u
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	ics "github.com/arran4/golang-ical"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/icalserver"
+)
+
+type ServeIcalCmdOpts struct {
+	listen   string
+	interval int
+	year     uint
+	semester gaps.Semester
+	teachers []uint
+	rooms    []uint
+}
+
+var (
+	serveIcalOpts = &ServeIcalCmdOpts{
+		semester: gaps.All,
+	}
+
+	serveIcalCmd = &cobra.Command{
+		Use:   "serve-ical",
+		Short: "Serves your GAPS schedules as iCal subscriptions for calendar applications",
+		Long: `Serves your GAPS schedules as iCal subscriptions for calendar applications.
+
+The following URLs are available, each schedule being refreshed from GAPS in the background:
+  /student.ics        your own schedule
+  /student/<id>.ics   the schedule of another student
+  /teacher/<id>.ics   the schedule of a teacher
+  /room/<id>.ics      the schedule of a room
+
+Without an access token, only your own schedule and the ones given with --teacher and --room are served.
+When an access token is configured, any schedule can be requested, and the token must be given either as
+the token query parameter or as a bearer token in the Authorization header.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if serveIcalOpts.interval <= 0 {
+				return fmt.Errorf("invalid interval %d, must be a positive number of seconds", serveIcalOpts.interval)
+			}
+
+			server := icalserver.New(
+				fetchIcalTarget,
+				time.Duration(serveIcalOpts.interval)*time.Second,
+				credentialsViper.GetString(ServeIcalTokenViperKey.Key()),
+			)
+
+			targets := []icalserver.Target{{Type: gaps.StudentSchedule}}
+			for _, id := range serveIcalOpts.teachers {
+				targets = append(targets, icalserver.Target{Type: gaps.TeacherSchedule, Id: id})
+			}
+			for _, id := range serveIcalOpts.rooms {
+				targets = append(targets, icalserver.Target{Type: gaps.RoomSchedule, Id: id})
+			}
+
+			log.Info("Fetching initial schedules")
+			server.Preload(ctx, targets...)
+			go server.Run(ctx)
+
+			srv := &http.Server{
+				Addr:              serveIcalOpts.listen,
+				Handler:           server,
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				srv.Shutdown(shutdownCtx)
+			}()
+
+			for _, target := range targets {
+				log.Infof("Serving %s schedule on http://%s%s", target, serveIcalOpts.listen, target.Path())
+			}
+			if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+
+			return nil
+		},
+	}
+)
+
+func init() {
+	serveIcalCmd.Flags().StringVar(&serveIcalOpts.listen, "listen", "127.0.0.1:8080", "Address to listen on")
+	serveIcalCmd.Flags().IntVar(&serveIcalOpts.interval, "interval", 3600, "Interval between each schedule refresh (in seconds)")
+	serveIcalCmd.Flags().UintVarP(&serveIcalOpts.year, "year", "y", currentAcademicYear(),
+		"Academic year (year at the start of the academic year, e.g. 2020 for 2020-2021 academic year)")
+	serveIcalCmd.Flags().VarP(&serveIcalOpts.semester, "semester", "s", "Academic semester (S1, S2, all)")
+	serveIcalCmd.Flags().UintSliceVar(&serveIcalOpts.teachers, "teacher", nil, "GAPS ids of teachers to fetch on startup")
+	serveIcalCmd.Flags().UintSliceVar(&serveIcalOpts.rooms, "room", nil, "GAPS ids of rooms to fetch on startup")
+
+	serveIcalCmd.Flags().String(ServeIcalTokenViperKey.Flag(), "", "Access token required to fetch the calendars, also allowing any schedule to be requested (default is no token)")
+	credentialsViper.BindPFlag(ServeIcalTokenViperKey.Key(), serveIcalCmd.Flags().Lookup(ServeIcalTokenViperKey.Flag()))
+
+	rootCmd.AddCommand(serveIcalCmd)
+}
+
+func fetchIcalTarget(ctx context.Context, target icalserver.Target) (*ics.Calendar, error) {
+	cfg, err := buildTokenClientConfiguration(ctx, persistToken)
+	if err != nil {
+		return nil, err
+	}
+
+	calendars, err := fetchScheduleTerms(ctx, log.WithField("target", target), cfg,
+		serveIcalOpts.year, serveIcalOpts.semester.ScheduleTerms(), target.Type, target.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return gaps.MergeCalendars(calendars...), nil
+}
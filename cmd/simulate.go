@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/output"
+	"lutonite.dev/gaps-cli/parser"
+	"lutonite.dev/gaps-cli/simulator"
+)
+
+type SimulateCmdOpts struct {
+	printer       output.Printer
+	year          uint
+	semester      gaps.Semester
+	class         string
+	grades        []string
+	exam          string
+	examWeight    float64
+	target        float64
+	ignorePending bool
+}
+
+var (
+	simulateOpts = &SimulateCmdOpts{
+		semester: currentSemester(),
+	}
+
+	simulateCmd = &cobra.Command{
+		Use:   "simulate",
+		Short: "Simulates hypothetical grades and computes the grade needed to pass a class",
+		Example: `  gaps-cli simulate --class ARN --add "Cours:?:50" --target 4.5
+  gaps-cli simulate --class ARN --add "Laboratoire:5.5:30:Labo 3" --exam 4`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var hypotheses []simulator.Hypothesis
+			for _, grade := range simulateOpts.grades {
+				h, err := simulator.ParseHypothesis(grade)
+				if err != nil {
+					return err
+				}
+
+				hypotheses = append(hypotheses, h)
+			}
+
+			exam := parser.GradeValue{}
+			if simulateOpts.exam != "" && simulateOpts.exam != "?" {
+				if exam = parser.ParseGradeValue(simulateOpts.exam); !exam.IsGraded() {
+					return fmt.Errorf("invalid exam grade: %s", simulateOpts.exam)
+				}
+			}
+
+			cfg, err := buildTokenClientConfiguration(cmd.Context(), rememberToken)
+			if err != nil {
+				return err
+			}
+			action := gaps.NewSemesterGradesAction(cfg, simulateOpts.year, simulateOpts.semester)
+			action.ClassFilter = simulateOpts.class
+			classes, err := action.FetchGradesContext(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			result, err := simulator.Simulate(classes[0], hypotheses, simulator.Options{
+				Exam:          exam,
+				ExamWeight:    simulateOpts.examWeight / 100,
+				Target:        simulateOpts.target,
+				IgnorePending: simulateOpts.ignorePending,
+			})
+			if err != nil {
+				return err
+			}
+
+			return simulateOpts.printer.Print(output.View{
+				Data: result,
+				Table: func(t *output.Table) {
+					simulationTable(t, result)
+				},
+			})
+		},
+	}
+)
+
+func init() {
+	simulateOpts.printer.AddFlags(simulateCmd.Flags(), output.TableFormat)
+	simulateCmd.Flags().UintVarP(&simulateOpts.year, "year", "y", currentAcademicYear(),
+		"Academic year (year at the start of the academic year, e.g. 2020 for 2020-2021 academic year)")
+	simulateCmd.Flags().VarP(&simulateOpts.semester, "semester", "s", "Academic semester (S1, S2, all)")
+	simulateCmd.Flags().StringVar(&simulateOpts.class, "class", "", "Class to simulate")
+	simulateCmd.Flags().StringArrayVarP(&simulateOpts.grades, "add", "a", nil,
+		"Hypothetical grade <group>:<grade|?>:<weight>[:<description>], ? marks a remaining assessment (repeatable)")
+	simulateCmd.Flags().StringVar(&simulateOpts.exam, "exam", "?", "Exam grade for classes with an exam, ? to solve for it")
+	simulateCmd.Flags().Float64Var(&simulateOpts.examWeight, "exam-weight", 50, "Weight of the exam in the final grade (in %)")
+	simulateCmd.Flags().Float64VarP(&simulateOpts.target, "target", "t", simulator.PassingGrade, "Final grade to reach")
+	simulateCmd.Flags().BoolVar(&simulateOpts.ignorePending, "ignore-pending", false,
+		"Do not count the assessments listed without a grade on GAPS as remaining")
+	_ = simulateCmd.MarkFlagRequired("class")
+
+	rootCmd.AddCommand(simulateCmd)
+}
+
+func simulationTable(t *output.Table, result *simulator.Result) {
+	t.Style().Options.SeparateRows = true
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 1, AutoMerge: true},
+		{Number: 2, Align: text.AlignCenter, AlignHeader: text.AlignCenter},
+		{Number: 3, Align: text.AlignCenter, AlignHeader: text.AlignCenter},
+		{Number: 4, Align: text.AlignCenter, AlignHeader: text.AlignCenter},
+		{Number: 5, Align: text.AlignCenter, AlignHeader: text.AlignCenter, AlignFooter: text.AlignCenter},
+	})
+
+	t.AppendHeader(table.Row{"Class", "Group", "Weight", "Remaining", "Mean"})
+
+	classDesc := result.Class
+	if result.HasExam {
+		classDesc += " (E)"
+	}
+
+	for _, group := range result.Groups {
+		remaining := fmt.Sprintf("%d", group.Pending)
+		if group.Simulated > 0 {
+			remaining += fmt.Sprintf(" (+%d simulated)", group.Simulated)
+		}
+
+		t.AppendRow(table.Row{classDesc, group.Name, fmt.Sprintf("%d%%", group.Weight), remaining, group.Mean.Format(2)})
+	}
+
+	t.AppendFooter(table.Row{"", "", "", "MEAN", result.Mean.Format(2)})
+	if result.HasExam {
+		t.AppendFooter(table.Row{"", "", "", "FINAL", result.Final.Format(2)})
+	}
+
+	required := text.Colors{text.FgGreen}.Sprint("nothing remaining")
+	switch {
+	case result.Remaining > 0 && !result.Required.IsGraded():
+		required = "-"
+	case result.Remaining > 0 && !result.Reachable:
+		required = text.Colors{text.FgRed, text.Bold}.Sprintf("%s (unreachable)", result.Required)
+	case result.Remaining > 0:
+		required = text.Colors{text.FgYellow, text.Bold}.Sprint(result.Required.Format(1))
+	}
+
+	t.AppendFooter(table.Row{"", "", "", fmt.Sprintf("REQUIRED FOR %.1f", result.Target), required})
+}
@@ -9,8 +9,9 @@ import (
 
 var (
 	versionCmd = &cobra.Command{
-		Use:   "version",
-		Short: "Print the current build version",
+		Use:         "version",
+		Short:       "Print the current build version",
+		Annotations: map[string]string{noCredentialsAnnotation: ""},
 		Run: func(cmd *cobra.Command, args []string) {
 			log.Debug("fetching version")
 			fmt.Println(version.GetStr())
@@ -0,0 +1,162 @@
+package credentials_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"lutonite.dev/gaps-cli/credentials"
+)
+
+func passphrase(value string) credentials.Passphrase {
+	return func(bool) (string, error) {
+		return value, nil
+	}
+}
+
+func TestEncryptedFile(t *testing.T) {
+	path := t.TempDir() + "/credentials.enc"
+
+	f := credentials.NewEncryptedFile(path, passphrase("correct horse"))
+	if _, err := f.Get("login.password"); !errors.Is(err, credentials.ErrNotFound) {
+		t.Fatalf("Get() without file = %v, want %v", err, credentials.ErrNotFound)
+	}
+	if err := f.Set("login.password", "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Set("login.token.value", "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Error("the file contains the secret in clear")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("file mode = %o, want 600", info.Mode().Perm())
+	}
+
+	// a new instance decrypts the file with the same passphrase
+	reopened := credentials.NewEncryptedFile(path, passphrase("correct horse"))
+	if value, err := reopened.Get("login.password"); err != nil || value != "hunter2" {
+		t.Errorf("Get() = %q, %v, want hunter2", value, err)
+	}
+
+	wrong := credentials.NewEncryptedFile(path, passphrase("wrong"))
+	if _, err := wrong.Get("login.password"); !errors.Is(err, credentials.ErrWrongPassphrase) {
+		t.Errorf("Get() with a wrong passphrase = %v, want %v", err, credentials.ErrWrongPassphrase)
+	}
+
+	// the file is removed with its last secret
+	if err := reopened.Delete("login.password"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Delete("login.token.value"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file still exists without secrets: %v", err)
+	}
+}
+
+func TestEncryptedFileFormat(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{
+		"unsupported.enc": `{"version":2,"kdf":"argon2"}`,
+		"corrupted.enc":   `not json`,
+	} {
+		if err := os.WriteFile(dir+"/"+name, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f := credentials.NewEncryptedFile(dir+"/unsupported.enc", passphrase("secret"))
+	if _, err := f.Get("login.password"); !errors.Is(err, credentials.ErrUnsupportedFormat) {
+		t.Errorf("Get() of an unsupported file = %v, want %v", err, credentials.ErrUnsupportedFormat)
+	}
+
+	f = credentials.NewEncryptedFile(dir+"/corrupted.enc", passphrase("secret"))
+	if _, err := f.Get("login.password"); !errors.Is(err, credentials.ErrWrongPassphrase) {
+		t.Errorf("Get() of a corrupted file = %v, want %v", err, credentials.ErrWrongPassphrase)
+	}
+}
+
+func TestPlaintext(t *testing.T) {
+	path := t.TempDir() + "/credentials.yaml"
+	p := credentials.NewPlaintext(path)
+
+	if err := p.Set("login.password", "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Set("login.token.value", "token"); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := p.Get("login.token.value"); err != nil || value != "token" {
+		t.Errorf("Get() = %q, %v, want token", value, err)
+	}
+	if _, err := p.Get("login.token"); !errors.Is(err, credentials.ErrNotFound) {
+		t.Errorf("Get() of a section = %v, want %v", err, credentials.ErrNotFound)
+	}
+	if err := p.Delete("scraper.accounts.work.password"); err != nil {
+		t.Errorf("Delete() of a missing secret = %v", err)
+	}
+
+	// deleting the token prunes its emptied section
+	if err := p.Delete("login.token.value"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != "login:\n    password: hunter2\n" {
+		t.Errorf("file = %q, want only the password", got)
+	}
+
+	if err := p.Delete("login.password"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file still exists without secrets: %v", err)
+	}
+}
+
+func TestRestrict(t *testing.T) {
+	dir := t.TempDir()
+	if err := credentials.Restrict(dir + "/missing.yaml"); err != nil {
+		t.Errorf("Restrict() of a missing file = %v", err)
+	}
+
+	// files written by previous versions were readable by everyone
+	path := dir + "/credentials.yaml"
+	if err := os.WriteFile(path, []byte("login:\n    password: hunter2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if value, err := credentials.NewPlaintext(path).Get("login.password"); err != nil || value != "hunter2" {
+		t.Fatalf("Get() = %q, %v, want hunter2", value, err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("file mode after read = %o, want 600", info.Mode().Perm())
+	}
+}
+
+func TestParseBackend(t *testing.T) {
+	for _, b := range credentials.Backends {
+		if parsed, err := credentials.ParseBackend(string(b)); err != nil || parsed != b {
+			t.Errorf("ParseBackend(%s) = %s, %v", b, parsed, err)
+		}
+	}
+
+	if _, err := credentials.ParseBackend("vault"); err == nil {
+		t.Error("ParseBackend(vault) succeeded")
+	}
+}
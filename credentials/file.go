@@ -0,0 +1,205 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// scrypt parameters of new files, as recommended for interactive logins.
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// Passphrase returns the passphrase of an encrypted file, create tells whether the file is about to be created
+// so that it may e.g. be asked twice.
+type Passphrase func(create bool) (string, error)
+
+// EncryptedFile stores secrets in a file encrypted with AES-256-GCM, using a key derived from a passphrase with
+// scrypt. The whole file is decrypted on first access and rewritten on each change, the passphrase is only
+// requested when needed.
+type EncryptedFile struct {
+	path       string
+	passphrase Passphrase
+
+	key     string
+	secrets map[string]string
+}
+
+// encryptedFile is the JSON layout of the file, the parameters are stored so they can be raised later on.
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	Kdf        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func NewEncryptedFile(path string, passphrase Passphrase) *EncryptedFile {
+	return &EncryptedFile{path: path, passphrase: passphrase}
+}
+
+func (f *EncryptedFile) Backend() Backend {
+	return FileBackend
+}
+
+func (f *EncryptedFile) Get(key string) (string, error) {
+	if err := f.load(); err != nil {
+		return "", err
+	}
+
+	value, ok := f.secrets[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	return value, nil
+}
+
+func (f *EncryptedFile) Set(key string, value string) error {
+	if err := f.load(); err != nil {
+		return err
+	}
+
+	f.secrets[key] = value
+	return f.save()
+}
+
+func (f *EncryptedFile) Delete(key string) error {
+	if err := f.load(); err != nil {
+		return err
+	}
+
+	if _, ok := f.secrets[key]; !ok {
+		return nil
+	}
+
+	delete(f.secrets, key)
+	if len(f.secrets) == 0 {
+		return os.Remove(f.path)
+	}
+
+	return f.save()
+}
+
+func (f *EncryptedFile) load() error {
+	if f.secrets != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		f.secrets = make(map[string]string)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return ErrWrongPassphrase
+	}
+	if file.Version != 1 || file.Kdf != "scrypt" {
+		return ErrUnsupportedFormat
+	}
+
+	if err := f.unlock(false); err != nil {
+		return err
+	}
+
+	aead, err := newAead(f.key, file.Salt, file.N, file.R, file.P)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := aead.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return ErrWrongPassphrase
+	}
+
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return ErrWrongPassphrase
+	}
+
+	f.secrets = secrets
+	return nil
+}
+
+// save encrypts the secrets with a fresh salt and nonce.
+func (f *EncryptedFile) save() error {
+	plaintext, err := json.Marshal(f.secrets)
+	if err != nil {
+		return err
+	}
+
+	file := encryptedFile{Version: 1, Kdf: "scrypt", N: scryptN, R: scryptR, P: scryptP}
+	file.Salt = make([]byte, 16)
+	if _, err := rand.Read(file.Salt); err != nil {
+		return err
+	}
+
+	if err := f.unlock(true); err != nil {
+		return err
+	}
+
+	aead, err := newAead(f.key, file.Salt, file.N, file.R, file.P)
+	if err != nil {
+		return err
+	}
+
+	file.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Ciphertext = aead.Seal(nil, file.Nonce, plaintext, nil)
+
+	data, err := json.MarshalIndent(&file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFile(f.path, data)
+}
+
+func (f *EncryptedFile) unlock(create bool) error {
+	if f.key != "" {
+		return nil
+	}
+
+	passphrase, err := f.passphrase(create)
+	if err != nil {
+		return err
+	}
+	if passphrase == "" {
+		return errors.New("empty passphrase")
+	}
+
+	f.key = passphrase
+	return nil
+}
+
+func newAead(passphrase string, salt []byte, n int, r int, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, n, r, p, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
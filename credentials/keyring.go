@@ -0,0 +1,52 @@
+package credentials
+
+import (
+	"errors"
+
+	"github.com/zalando/go-keyring"
+)
+
+// probeKey is looked up to check whether the keyring can be reached at all.
+const probeKey = "probe"
+
+// Keyring stores secrets in the OS keyring: the Secret Service (GNOME Keyring, KWallet) over D-Bus on Linux,
+// the Keychain on macOS and the Credential Manager on Windows.
+type Keyring struct {
+	service string
+}
+
+// NewKeyring returns a store keeping its secrets under the given service name.
+func NewKeyring(service string) *Keyring {
+	return &Keyring{service: service}
+}
+
+// Available reports whether the OS keyring can be used, e.g. it is usually not on headless servers.
+func (k *Keyring) Available() bool {
+	_, err := keyring.Get(k.service, probeKey)
+	return err == nil || errors.Is(err, keyring.ErrNotFound)
+}
+
+func (k *Keyring) Backend() Backend {
+	return KeyringBackend
+}
+
+func (k *Keyring) Get(key string) (string, error) {
+	value, err := keyring.Get(k.service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+
+	return value, err
+}
+
+func (k *Keyring) Set(key string, value string) error {
+	return keyring.Set(k.service, key, value)
+}
+
+func (k *Keyring) Delete(key string) error {
+	if err := keyring.Delete(k.service, key); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package credentials
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Plaintext stores secrets unencrypted in the nested YAML file used by previous versions, readable by its owner
+// only.
+type Plaintext struct {
+	path string
+}
+
+func NewPlaintext(path string) *Plaintext {
+	return &Plaintext{path: path}
+}
+
+func (p *Plaintext) Backend() Backend {
+	return PlaintextBackend
+}
+
+func (p *Plaintext) Get(key string) (string, error) {
+	values, err := p.read()
+	if err != nil {
+		return "", err
+	}
+
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		nested, ok := values[part].(map[string]any)
+		if !ok {
+			return "", ErrNotFound
+		}
+		values = nested
+	}
+
+	value, ok := values[parts[len(parts)-1]].(string)
+	if !ok || value == "" {
+		return "", ErrNotFound
+	}
+
+	return value, nil
+}
+
+func (p *Plaintext) Set(key string, value string) error {
+	return p.update(key, value)
+}
+
+func (p *Plaintext) Delete(key string) error {
+	return p.update(key, nil)
+}
+
+func (p *Plaintext) update(key string, value any) error {
+	values, err := p.read()
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(key, ".")
+	parents := []map[string]any{values}
+	parent := values
+	for _, part := range parts[:len(parts)-1] {
+		nested, ok := parent[part].(map[string]any)
+		if !ok {
+			if value == nil {
+				return nil
+			}
+			nested = make(map[string]any)
+			parent[part] = nested
+		}
+		parent = nested
+		parents = append(parents, nested)
+	}
+
+	if value == nil {
+		delete(parent, parts[len(parts)-1])
+
+		// prune the emptied sections, and the file once it holds no secret anymore
+		for i := len(parents) - 1; i > 0 && len(parents[i]) == 0; i-- {
+			delete(parents[i-1], parts[i-1])
+		}
+		if len(values) == 0 {
+			if err := os.Remove(p.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			return nil
+		}
+	} else {
+		parent[parts[len(parts)-1]] = value
+	}
+
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(p.path, data)
+}
+
+func (p *Plaintext) read() (map[string]any, error) {
+	// files written by previous versions were readable by everyone
+	if err := Restrict(p.path); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]any)
+	data, err := os.ReadFile(p.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	if values == nil {
+		values = make(map[string]any)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,94 @@
+// Package credentials stores the secrets of gaps-cli, such as the einet AAI password and the GAPS session token,
+// in the OS keyring, in a passphrase-encrypted file or, when explicitly asked for, in a plaintext file.
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Backend names a kind of Store.
+type Backend string
+
+const (
+	KeyringBackend   Backend = "keyring"
+	FileBackend      Backend = "file"
+	PlaintextBackend Backend = "plaintext"
+)
+
+// Backends lists every backend, in order of preference.
+var Backends = []Backend{KeyringBackend, FileBackend, PlaintextBackend}
+
+// ParseBackend validates a backend name.
+func ParseBackend(name string) (Backend, error) {
+	for _, b := range Backends {
+		if string(b) == name {
+			return b, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown credentials backend %q, expected one of %v", name, Backends)
+}
+
+var (
+	ErrNotFound          = errors.New("secret not found")
+	ErrWrongPassphrase   = errors.New("wrong passphrase or corrupted credentials file")
+	ErrKeyringUnusable   = errors.New("no usable OS keyring")
+	ErrUnsupportedFormat = errors.New("unsupported credentials file format")
+)
+
+// Store persists secrets by key, keys being the viper keys of the secrets, e.g. login.password.
+type Store interface {
+	Backend() Backend
+	// Get returns ErrNotFound when the secret isn't stored.
+	Get(key string) (string, error)
+	Set(key string, value string) error
+	// Delete doesn't fail when the secret isn't stored.
+	Delete(key string) error
+}
+
+// writeFile atomically replaces a file readable by its owner only.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Restrict removes the group and other permissions of an existing file, as written by previous versions.
+func Restrict(path string) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.Mode().Perm()&0077 == 0 {
+		return nil
+	}
+
+	return os.Chmod(path, info.Mode().Perm()&0700)
+}
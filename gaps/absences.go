@@ -1,6 +1,7 @@
 package gaps
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 
@@ -20,7 +21,11 @@ func NewAbsencesAction(config *TokenClientConfiguration, year uint) *AbsencesAct
 }
 
 func (a *AbsencesAction) FetchAbsences() (*parser.AbsenceReport, error) {
-	req, err := a.cfg.buildRequest("POST", "/consultation/etudiant/")
+	return a.FetchAbsencesContext(context.Background())
+}
+
+func (a *AbsencesAction) FetchAbsencesContext(ctx context.Context) (*parser.AbsenceReport, error) {
+	req, err := a.cfg.buildRequest(ctx, "POST", "/consultation/etudiant/")
 	if err != nil {
 		return nil, err
 	}
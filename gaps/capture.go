@@ -0,0 +1,433 @@
+package gaps
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Redacted replaces every secret or personal value in captures.
+const Redacted = "REDACTED"
+
+// RedactedStudentId replaces the student id in captures, it has to stay a number for the pages to parse.
+const RedactedStudentId = 99999
+
+// yearPlaceholder replaces the academic year of a capture when matching requests, so that captures keep
+// matching once the academic year rolled over.
+const yearPlaceholder = "{year}"
+
+var (
+	// ErrNoCapture is returned by the Replayer when no capture matches a request.
+	ErrNoCapture = errors.New("no matching capture")
+
+	redactedFormFields = []string{"login", "password"}
+	redactedHeaders    = []string{"Content-Type", "Location", "Set-Cookie"}
+	personalPatterns   = []*regexp.Regexp{
+		// student name and orientation in the absences report
+		regexp.MustCompile(`(class=["']?(?:l_cell )?s_cell["']?>)[^<]*`),
+	}
+	studentIdRegex = regexp.MustCompile(`const DEFAULT_STUDENT_ID = (\d+);`)
+)
+
+// Capture is a single request/response exchanged with GAPS, with secrets and personal data redacted.
+type Capture struct {
+	Method string `json:"method"`
+	Url    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+	// Year is the academic year at the time of the recording, it matches the current one when replaying
+	Year     uint            `json:"year,omitempty"`
+	Response CaptureResponse `json:"response"`
+}
+
+type CaptureResponse struct {
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+	// Encoding is "base64" when the body is not valid UTF-8, e.g. ISO-8859-1 pages
+	Encoding string `json:"encoding,omitempty"`
+}
+
+func (c *CaptureResponse) setBody(body string) {
+	if utf8.ValidString(body) {
+		c.Body = body
+		return
+	}
+
+	c.Body = base64.StdEncoding.EncodeToString([]byte(body))
+	c.Encoding = "base64"
+}
+
+func (c *CaptureResponse) body() (string, error) {
+	if c.Encoding != "base64" {
+		return c.Body, nil
+	}
+
+	body, err := base64.StdEncoding.DecodeString(c.Body)
+	return string(body), err
+}
+
+// key identifies the request of the capture, its academic year being replaced by a placeholder.
+func (c *Capture) key() string {
+	if c.Year == 0 {
+		return c.Method + " " + c.Url + "\n" + c.Body
+	}
+
+	year := strconv.FormatUint(uint64(c.Year), 10)
+	body := c.Body
+	if form, err := url.ParseQuery(body); err == nil && body != "" {
+		body = mapForm(form, func(value string) string {
+			return replaceNumber(value, year, yearPlaceholder)
+		})
+	}
+
+	return c.Method + " " + replaceNumber(c.Url, year, yearPlaceholder) + "\n" + body
+}
+
+// redactor replaces known secrets, sensitive form fields and personal data in captured exchanges.
+type redactor struct {
+	mu        sync.Mutex
+	secrets   map[string]bool
+	studentId string
+}
+
+// RedactStudentId replaces the student id by RedactedStudentId, it is otherwise learnt from the student page.
+func (r *redactor) RedactStudentId(id uint) {
+	if id == 0 || id == RedactedStudentId {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.studentId = strconv.FormatUint(uint64(id), 10)
+}
+
+func (r *redactor) Redact(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, value := range values {
+		if value != "" && value != Redacted {
+			r.secrets[value] = true
+		}
+	}
+}
+
+// learn registers the session tokens found in the request and response cookies as secrets, along with the
+// student id found in the response body.
+func (r *redactor) learn(req *http.Request, res *http.Response, body []byte) {
+	if matches := studentIdRegex.FindSubmatch(body); matches != nil {
+		id, err := strconv.ParseUint(string(matches[1]), 10, 32)
+		if err == nil {
+			r.RedactStudentId(uint(id))
+		}
+	}
+
+	for _, cookie := range req.Cookies() {
+		if cookie.Name == "GAPSSESSID" {
+			r.Redact(cookie.Value)
+		}
+	}
+
+	if res != nil {
+		for _, cookie := range res.Cookies() {
+			if cookie.Name == "GAPSSESSID" {
+				r.Redact(cookie.Value)
+			}
+		}
+	}
+}
+
+func (r *redactor) text(text string) string {
+	// sajax replies are JSON encoded strings, redact their decoded content
+	if strings.HasPrefix(text, `+:"`) {
+		var inner string
+		if err := json.Unmarshal([]byte(text[2:]), &inner); err == nil {
+			encoded, _ := json.Marshal(r.text(inner))
+			return "+:" + string(encoded)
+		}
+	}
+
+	r.mu.Lock()
+	secrets := make([]string, 0, len(r.secrets))
+	for secret := range r.secrets {
+		secrets = append(secrets, secret)
+	}
+	studentId := r.studentId
+	r.mu.Unlock()
+
+	// replace the longest secrets first, in case one contains another
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, Redacted)
+		if escaped := url.QueryEscape(secret); escaped != secret {
+			text = strings.ReplaceAll(text, escaped, Redacted)
+		}
+	}
+
+	for _, pattern := range personalPatterns {
+		text = pattern.ReplaceAllString(text, "${1}"+Redacted)
+	}
+
+	if studentId != "" {
+		text = replaceNumber(text, studentId, strconv.Itoa(RedactedStudentId))
+	}
+
+	return text
+}
+
+func (r *redactor) body(body string) string {
+	form, err := url.ParseQuery(body)
+	if err != nil || body == "" {
+		return r.text(body)
+	}
+
+	for _, field := range redactedFormFields {
+		if form.Has(field) {
+			form.Set(field, Redacted)
+		}
+	}
+
+	// numbers are only told apart from their surroundings once decoded, e.g. in JSON encoded sajax arguments
+	return mapForm(form, r.text)
+}
+
+func (r *redactor) request(req *http.Request, body []byte) *Capture {
+	return &Capture{
+		Method: req.Method,
+		Url:    r.text(req.URL.RequestURI()),
+		Body:   r.body(string(body)),
+	}
+}
+
+func (r *redactor) header(header http.Header) http.Header {
+	redacted := http.Header{}
+	for _, name := range redactedHeaders {
+		for _, value := range header.Values(name) {
+			redacted.Add(name, r.text(value))
+		}
+	}
+
+	return redacted
+}
+
+// mapForm applies fn to every value of the form and encodes it.
+func mapForm(form url.Values, fn func(value string) string) string {
+	mapped := url.Values{}
+	for key, values := range form {
+		for _, value := range values {
+			mapped.Add(key, fn(value))
+		}
+	}
+
+	return mapped.Encode()
+}
+
+// replaceNumber replaces the occurrences of number in text that are not part of a longer number.
+func replaceNumber(text string, number string, replacement string) string {
+	re := regexp.MustCompile(`(^|\D)` + regexp.QuoteMeta(number) + `(\D|$)`)
+	// a separator is consumed by each match, adjacent occurrences need another pass
+	for re.MatchString(text) {
+		text = re.ReplaceAllString(text, "${1}"+replacement+"${2}")
+	}
+
+	return text
+}
+
+func readBody(body io.ReadCloser) ([]byte, io.ReadCloser, error) {
+	if body == nil || body == http.NoBody {
+		return nil, body, nil
+	}
+
+	data, err := io.ReadAll(body)
+	body.Close()
+	return data, io.NopCloser(bytes.NewReader(data)), err
+}
+
+// Recorder is an http.RoundTripper writing every exchange with GAPS to a directory,
+// one redacted JSON capture per request, to be served back later by a Replayer.
+type Recorder struct {
+	redactor
+	// AcademicYear is written along with each capture, see Capture.Year
+	AcademicYear uint
+
+	dir  string
+	next http.RoundTripper
+	seq  int
+}
+
+// NewRecorder creates the capture directory and returns a Recorder sending requests through next,
+// or http.DefaultTransport when nil.
+func NewRecorder(dir string, next http.RoundTripper) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	// append to previous recordings of the same directory
+	existing, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recorder{
+		redactor: redactor{secrets: make(map[string]bool)},
+		dir:      dir,
+		next:     next,
+		seq:      len(existing),
+	}, nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, body, err := readBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = body
+
+	res, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, body, err := readBody(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	res.Body = body
+
+	r.learn(req, res, resBody)
+	capture := r.request(req, reqBody)
+	capture.Year = r.AcademicYear
+	capture.Response = CaptureResponse{
+		StatusCode: res.StatusCode,
+		Header:     r.header(res.Header),
+	}
+	capture.Response.setBody(r.text(string(resBody)))
+
+	data, err := json.MarshalIndent(capture, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.seq++
+	name := filepath.Join(r.dir, fmt.Sprintf("%04d.json", r.seq))
+	r.mu.Unlock()
+
+	if err := os.WriteFile(name, data, 0600); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Replayer is an http.RoundTripper answering requests from the captures written by a Recorder,
+// matching them on method, URL and body once redacted. Identical requests are answered in recording
+// order, the last matching capture being reused once exhausted.
+type Replayer struct {
+	redactor
+	// AcademicYear is the current academic year, requested in place of the one of the captures
+	AcademicYear uint
+
+	captures map[string][]*Capture
+}
+
+// NewReplayer loads every capture of the given directory.
+func NewReplayer(dir string) (*Replayer, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no capture found in %s", dir)
+	}
+
+	sort.Strings(files)
+	r := &Replayer{
+		redactor: redactor{secrets: make(map[string]bool)},
+		captures: make(map[string][]*Capture),
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		capture := new(Capture)
+		if err := json.Unmarshal(data, capture); err != nil {
+			return nil, fmt.Errorf("invalid capture %s: %w", file, err)
+		}
+
+		r.captures[capture.key()] = append(r.captures[capture.key()], capture)
+	}
+
+	return r, nil
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, body, err := readBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = body
+
+	r.learn(req, nil, nil)
+	request := r.request(req, reqBody)
+	request.Year = r.AcademicYear
+	key := request.key()
+
+	r.mu.Lock()
+	candidates := r.captures[key]
+	if len(candidates) == 0 {
+		// captures of previous versions have no year
+		request.Year = 0
+		key = request.key()
+		candidates = r.captures[key]
+	}
+	if len(candidates) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w for %s %s", ErrNoCapture, req.Method, req.URL.RequestURI())
+	}
+
+	capture := candidates[0]
+	if len(candidates) > 1 {
+		r.captures[key] = candidates[1:]
+	}
+	r.mu.Unlock()
+
+	resBody, err := capture.Response.body()
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", capture.Response.StatusCode, http.StatusText(capture.Response.StatusCode)),
+		StatusCode:    capture.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        capture.Response.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(resBody)),
+		ContentLength: int64(len(resBody)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,89 @@
+package gaps_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/gaps/gapstest"
+)
+
+// session logs in and fetches the pages containing the student id and the academic year through transport.
+func session(t *testing.T, baseUrl string, transport http.RoundTripper, year uint) {
+	t.Helper()
+	ctx := context.Background()
+
+	login := new(gaps.ClientConfiguration)
+	login.Init(baseUrl)
+	login.SetTransport(transport)
+
+	action := gaps.NewLoginAction(login, "jane.doe", "hunter2")
+	token, err := action.FetchTokenContext(ctx)
+	if err != nil {
+		t.Fatalf("FetchTokenContext: %v", err)
+	}
+
+	studentId, err := action.FetchStudentIdContext(ctx, token)
+	if err != nil {
+		t.Fatalf("FetchStudentIdContext: %v", err)
+	}
+
+	cfg := new(gaps.TokenClientConfiguration)
+	if err := cfg.InitToken(baseUrl, token, studentId); err != nil {
+		t.Fatalf("InitToken: %v", err)
+	}
+	cfg.SetTransport(transport)
+
+	if _, err := gaps.NewGradesAction(cfg, year).FetchGradesContext(ctx); err != nil {
+		t.Fatalf("FetchGradesContext: %v", err)
+	}
+	if _, err := gaps.NewAbsencesAction(cfg, year).FetchAbsencesContext(ctx); err != nil {
+		t.Fatalf("FetchAbsencesContext: %v", err)
+	}
+	if _, err := gaps.NewReportCardAction(cfg).FetchReportCardContext(ctx); err != nil {
+		t.Fatalf("FetchReportCardContext: %v", err)
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	fixtures := gapstest.DefaultFixtures()
+	fixtures.StudentId = 4242
+	srv := gapstest.NewServer(fixtures)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	recorder, err := gaps.NewRecorder(dir, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	recorder.AcademicYear = 2023
+	session(t, srv.URL, recorder, 2023)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no capture written: %v", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{"4242", "jane.doe", "hunter2", "Doe Jane"} {
+			if strings.Contains(string(data), secret) {
+				t.Errorf("%s contains %q", filepath.Base(file), secret)
+			}
+		}
+	}
+
+	// a year later, the current academic year is requested
+	replayer, err := gaps.NewReplayer(dir)
+	if err != nil {
+		t.Fatalf("NewReplayer: %v", err)
+	}
+	replayer.AcademicYear = 2024
+	session(t, "http://gaps.invalid", replayer, 2024)
+}
@@ -1,16 +1,18 @@
 package gaps
 
 import (
+	"context"
 	"fmt"
-	log "github.com/sirupsen/logrus"
-	"io"
 	"lutonite.dev/gaps-cli/_internal/version"
 	"lutonite.dev/gaps-cli/util"
 	"net/http"
 	"net/url"
-	"strings"
+	"time"
 )
 
+// DefaultTimeout bounds every request made to GAPS, regardless of the context deadline.
+const DefaultTimeout = time.Minute
+
 type ClientConfiguration struct {
 	client  *http.Client
 	baseUrl string
@@ -20,15 +22,29 @@ type TokenClientConfiguration struct {
 	ClientConfiguration
 	token     string
 	studentId uint
+
+	credentials    CredentialProvider
+	onTokenRefresh func(token string)
 }
 
 func (c *ClientConfiguration) Init(baseUrl string) {
 	c.baseUrl = baseUrl
 	c.client = &http.Client{
-		Jar: nil,
+		Jar:     nil,
+		Timeout: DefaultTimeout,
 	}
 }
 
+// SetTimeout overrides the overall timeout of the underlying HTTP client, zero disables it.
+func (c *ClientConfiguration) SetTimeout(timeout time.Duration) {
+	c.client.Timeout = timeout
+}
+
+// SetTransport changes how requests are sent to GAPS, e.g. to record or replay them.
+func (c *ClientConfiguration) SetTransport(transport http.RoundTripper) {
+	c.client.Transport = transport
+}
+
 func (c *ClientConfiguration) SetToken(token string) *TokenClientConfiguration {
 	return &TokenClientConfiguration{
 		ClientConfiguration: *c,
@@ -36,18 +52,19 @@ func (c *ClientConfiguration) SetToken(token string) *TokenClientConfiguration {
 	}
 }
 
-func (tc *TokenClientConfiguration) InitToken(baseUrl string, token string, studentId uint) {
+func (tc *TokenClientConfiguration) InitToken(baseUrl string, token string, studentId uint) error {
 	if token == "" {
-		log.Panic("you must be logged in to use this command, please run 'gaps-cli login'")
+		return ErrNotLoggedIn
 	}
 
 	tc.Init(baseUrl)
 	tc.token = token
 	tc.studentId = studentId
+	return nil
 }
 
-func (tc *TokenClientConfiguration) buildRequest(method string, path string) (*http.Request, error) {
-	req, err := http.NewRequest(method, tc.baseUrl+path, nil)
+func (tc *TokenClientConfiguration) buildRequest(ctx context.Context, method string, path string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, tc.baseUrl+path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -57,10 +74,23 @@ func (tc *TokenClientConfiguration) buildRequest(method string, path string) (*h
 	return req, nil
 }
 
-func (tc *TokenClientConfiguration) doForm(req *http.Request, data url.Values) (*http.Response, error) {
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Body = io.NopCloser(strings.NewReader(data.Encode()))
-	return tc.client.Do(req)
+// do sends the request and turns unsuccessful status codes into a StatusError.
+func (c *ClientConfiguration) do(req *http.Request) (*http.Response, error) {
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		res.Body.Close()
+		return nil, &StatusError{
+			Method:     req.Method,
+			Url:        req.URL.Redacted(),
+			StatusCode: res.StatusCode,
+		}
+	}
+
+	return res, nil
 }
 
 func (tc *TokenClientConfiguration) parseUrl() *url.URL {
@@ -79,7 +109,7 @@ func (tc *TokenClientConfiguration) addAuthCookie(req *http.Request) {
 	})
 }
 
-func (tc *TokenClientConfiguration) setUserAgent(req *http.Request) {
+func (c *ClientConfiguration) setUserAgent(req *http.Request) {
 	buildInfo := version.Get()
 	req.Header.Set(
 		"User-Agent",
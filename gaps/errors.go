@@ -0,0 +1,49 @@
+package gaps
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"lutonite.dev/gaps-cli/parser"
+)
+
+var (
+	// ErrInvalidCredentials is returned when GAPS refuses the given username and password.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrSessionExpired is returned when GAPS no longer accepts the session token (GAPSSESSID).
+	ErrSessionExpired = errors.New("session expired")
+	// ErrNotLoggedIn is returned when a token client is initialized without any token.
+	ErrNotLoggedIn = errors.New("you must be logged in to use this command, please run 'gaps-cli login'")
+	// ErrClassNotFound is returned when no class matches the class filter of a grades action.
+	ErrClassNotFound = errors.New("no class found")
+	// ErrUnexpectedStructure is returned when a GAPS page could not be parsed, see parser.ErrUnexpectedStructure.
+	ErrUnexpectedStructure = parser.ErrUnexpectedStructure
+)
+
+// SajaxError is returned when a sajax call is answered with an error by GAPS.
+type SajaxError = parser.SajaxError
+
+// StatusError is returned when GAPS answers with a non-successful HTTP status code.
+type StatusError struct {
+	Method     string
+	Url        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: unexpected status %d %s", e.Method, e.Url, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// IsServerError reports whether err was caused by GAPS itself failing (5xx status, rate limiting or
+// a sajax error), in which case retrying later is the appropriate reaction.
+func IsServerError(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError ||
+			statusErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	var sajaxErr *SajaxError
+	return errors.As(err, &sajaxErr)
+}
@@ -0,0 +1,193 @@
+package gapstest
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/parser"
+)
+
+// Fixtures is the data served by the fake GAPS server, pages are rendered from it
+// so that parsing them yields the same structures back.
+type Fixtures struct {
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	StudentId uint   `json:"studentId"`
+
+	// Grades by academic year, served by getStudentCCs regardless of the requested semester
+	Grades map[uint][]*parser.ClassGrades `json:"grades"`
+	// Absences by academic year, served by the smartReplacePart sajax call
+	Absences map[uint]*parser.AbsenceReport `json:"absences"`
+	// ReportCard is served by bulletin.php, ISO-8859-1 encoded
+	ReportCard []*parser.ModuleReport `json:"reportCard"`
+	// Schedules are served as iCal by /consultation/horaires/
+	Schedules []*Schedule `json:"schedules"`
+}
+
+// Schedule is a calendar as requested to /consultation/horaires/.
+type Schedule struct {
+	Year     uint          `json:"year"`
+	Semester uint          `json:"semester"`
+	Type     uint          `json:"type"`
+	Id       uint          `json:"id"`
+	Lessons  []gaps.Lesson `json:"lessons"`
+}
+
+// LoadFixtures reads fixtures from a JSON file, as written by encoding/json from a Fixtures value.
+func LoadFixtures(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := new(Fixtures)
+	if err := json.Unmarshal(data, fixtures); err != nil {
+		return nil, err
+	}
+
+	return fixtures, nil
+}
+
+// DefaultFixtures returns a small but representative data set for a single student.
+func DefaultFixtures() *Fixtures {
+	year := uint(2023)
+	lesson := func(uid string, summary string, location string, day int, hour int) gaps.Lesson {
+		start := time.Date(2023, time.September, 18+day, hour, 30, 0, 0, time.UTC)
+		return gaps.Lesson{
+			Uid:      uid,
+			Summary:  summary,
+			Location: location,
+			Start:    start,
+			End:      start.Add(90 * time.Minute),
+		}
+	}
+
+	return &Fixtures{
+		Username:  "jane.doe",
+		Password:  "hunter2",
+		StudentId: 12345,
+		Grades: map[uint][]*parser.ClassGrades{
+			year: {
+				{
+					Name:       "ARN",
+					GlobalMean: "4.8",
+					HasExam:    true,
+					GradeGroups: []*parser.GradeGroup{
+						{
+							Name:   "Cours",
+							Mean:   "4.5",
+							Weight: 50,
+							Grades: []*parser.Grade{
+								{
+									Description: "TE1",
+									Date:        time.Date(2023, time.October, 20, 0, 0, 0, 0, time.UTC),
+									Weight:      50,
+									Grade:       "4.0",
+									ClassMean:   "4.2",
+								},
+								{
+									Description: "TE2",
+									Date:        time.Date(2023, time.December, 8, 0, 0, 0, 0, time.UTC),
+									Weight:      50,
+									Grade:       "5.0",
+									ClassMean:   "4.6",
+								},
+							},
+						},
+						{
+							Name:   "Laboratoire",
+							Mean:   "5.1",
+							Weight: 50,
+							Grades: []*parser.Grade{
+								{
+									Description: "Labo 1",
+									Date:        time.Date(2023, time.November, 3, 0, 0, 0, 0, time.UTC),
+									Weight:      100,
+									Grade:       "5.1",
+									ClassMean:   "-",
+								},
+							},
+						},
+					},
+				},
+				{
+					Name:       "PCO",
+					GlobalMean: "-",
+					HasExam:    false,
+					GradeGroups: []*parser.GradeGroup{
+						{
+							Name:   "Cours",
+							Mean:   "-",
+							Weight: 100,
+						},
+					},
+				},
+			},
+		},
+		Absences: map[uint]*parser.AbsenceReport{
+			year: {
+				Student:     "Doe Jane",
+				Orientation: "Informatique logicielle",
+				Courses: []parser.CourseAbsence{
+					absence("ARN", 0, 2, 2, 0, 0, 2, 64, 96),
+					absence("PCO", 0, 6, 0, 0, 0, 0, 64, 96),
+				},
+			},
+		},
+		ReportCard: []*parser.ModuleReport{
+			{
+				Identifier:   "M-ARN",
+				Name:         "Apprentissage par réseaux de neurones",
+				Year:         2022,
+				PassingGrade: "4.0",
+				GlobalGrade:  "4.8",
+				Credits:      5,
+				Situation:    "Réussite",
+				Classes: []*parser.ModuleClass{
+					{
+						Identifier: "ARN",
+						Name:       "Apprentissage par réseaux de neurones",
+						Grades: []*parser.ClassGrade{
+							{Name: "Cours", Weight: 50, Grade: "4.5"},
+							{Name: "Laboratoire", Weight: 50, Grade: "5.1"},
+						},
+						Mean:   "4.8",
+						Weight: 100,
+					},
+				},
+			},
+		},
+		Schedules: []*Schedule{
+			{
+				Year:     year,
+				Semester: 0,
+				Type:     uint(gaps.StudentSchedule),
+				Id:       12345,
+				Lessons: []gaps.Lesson{
+					lesson("arn-c1", "ARN-C1-L1", "G01", 0, 8),
+					lesson("arn-l1", "ARN-L1-L1", "H02", 1, 13),
+					lesson("pco-c1", "PCO-C1-L1", "G02", 2, 10),
+				},
+			},
+		},
+	}
+}
+
+func absence(name string, ete int, term1 int, term2 int, term3 int, term4 int, justified int, relative int, absolute int) parser.CourseAbsence {
+	course := parser.CourseAbsence{
+		Name:            name,
+		Total:           ete + term1 + term2 + term3 + term4,
+		Justified:       justified,
+		RelativePeriods: relative,
+		AbsolutePeriods: absolute,
+	}
+
+	course.Periods.Ete = ete
+	course.Periods.Term1 = term1
+	course.Periods.Term2 = term2
+	course.Periods.Term3 = term3
+	course.Periods.Term4 = term4
+	return course
+}
@@ -0,0 +1,95 @@
+package gapstest
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+
+	ics "github.com/arran4/golang-ical"
+	"golang.org/x/text/encoding/charmap"
+	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/parser"
+)
+
+//go:embed templates/*.gohtml
+var templateFiles embed.FS
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"rowspan": func(group *parser.GradeGroup) int {
+		return len(group.Grades) + 1
+	},
+	"periods": renderPeriods,
+	"counter": renderCounter,
+	"inc": func(n uint) uint {
+		return n + 1
+	},
+}).ParseFS(templateFiles, "templates/*.gohtml"))
+
+func render(name string, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// renderPeriods renders the absence cells of every term, justified periods are reported on the first
+// term having enough absences, as GAPS does with its "n [j]" notation.
+func renderPeriods(course parser.CourseAbsence) []string {
+	terms := []int{course.Periods.Ete, course.Periods.Term1, course.Periods.Term2, course.Periods.Term3, course.Periods.Term4}
+	cells := make([]string, len(terms))
+	justified := course.Justified
+	for i, periods := range terms {
+		cells[i] = renderCounter(periods)
+		if justified > 0 && periods >= justified {
+			cells[i] = fmt.Sprintf("%d [%d]", periods, justified)
+			justified = 0
+		}
+	}
+
+	return cells
+}
+
+func renderCounter(n int) string {
+	if n == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%d", n)
+}
+
+func renderLatin1(name string, data any) ([]byte, error) {
+	page, err := render(name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return charmap.ISO8859_1.NewEncoder().Bytes([]byte(page))
+}
+
+func renderCalendar(lessons []gaps.Lesson) string {
+	cal := ics.NewCalendar()
+	cal.SetMethod(ics.MethodPublish)
+	cal.SetProductId("-//gaps-cli//gapstest//FR")
+	for _, lesson := range lessons {
+		event := cal.AddEvent(lesson.Uid)
+		event.SetSummary(lesson.Summary)
+		event.SetLocation(lesson.Location)
+		event.SetStartAt(lesson.Start)
+		event.SetEndAt(lesson.End)
+		event.SetDtStampTime(lesson.Start)
+	}
+
+	return cal.Serialize()
+}
+
+// sajax wraps a page in a sajax success reply, smart replace parts are separated like GAPS does.
+func sajax(parts ...string) string {
+	text := strings.Join(parts, "@££@")
+	quoted, _ := json.Marshal(text)
+	return "+:" + string(quoted)
+}
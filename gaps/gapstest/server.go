@@ -0,0 +1,278 @@
+// Package gapstest provides a fake GAPS server for offline integration testing, in the spirit of net/http/httptest.
+package gapstest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+
+	"lutonite.dev/gaps-cli/gaps"
+)
+
+const sessionCookie = "GAPSSESSID"
+
+// Gaps is a fake GAPS instance serving the endpoints used by the gaps package from its Fixtures.
+type Gaps struct {
+	// Fixtures may be modified between requests, as long as it is done through Update.
+	Fixtures *Fixtures
+
+	mu       sync.Mutex
+	sessions map[string]bool
+	requests int
+	mux      *http.ServeMux
+}
+
+// Server is a Gaps instance listening on a local loopback address.
+type Server struct {
+	*httptest.Server
+	*Gaps
+}
+
+// NewGaps returns a fake GAPS handler, e.g. to serve it on a real listener.
+func NewGaps(fixtures *Fixtures) *Gaps {
+	s := &Gaps{
+		Fixtures: fixtures,
+		sessions: make(map[string]bool),
+		mux:      http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/consultation/index.php", s.handleLogin)
+	s.mux.HandleFunc("/consultation/etudiant/", s.authenticated(s.handleStudent))
+	s.mux.HandleFunc("/consultation/controlescontinus/consultation.php", s.authenticated(s.handleGrades))
+	s.mux.HandleFunc("/consultation/notes/bulletin.php", s.authenticated(s.handleReportCard))
+	s.mux.HandleFunc("/consultation/horaires/", s.authenticated(s.handleSchedule))
+	return s
+}
+
+// NewServer starts and returns a new fake GAPS server, the caller should call Close when finished.
+func NewServer(fixtures *Fixtures) *Server {
+	s := NewUnstartedServer(fixtures)
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer returns a new fake GAPS server without starting it, so its configuration can be changed.
+func NewUnstartedServer(fixtures *Fixtures) *Server {
+	g := NewGaps(fixtures)
+	return &Server{
+		Server: httptest.NewUnstartedServer(g),
+		Gaps:   g,
+	}
+}
+
+func (s *Gaps) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	s.mu.Unlock()
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// Login creates a new valid session, as if the student logged in, and returns its token.
+func (s *Gaps) Login() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	token := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[token] = true
+	return token
+}
+
+// ExpireSessions invalidates every session, as GAPS does when it kills sessions early.
+func (s *Gaps) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = make(map[string]bool)
+}
+
+// Update applies fn to the fixtures while no request is being served.
+func (s *Gaps) Update(fn func(fixtures *Fixtures)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.Fixtures)
+}
+
+// Requests returns the number of requests served so far.
+func (s *Gaps) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// authenticated rejects requests without a valid session the way GAPS does: sajax calls get an empty
+// reply while pages redirect to the login form.
+func (s *Gaps) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(sessionCookie)
+
+		s.mu.Lock()
+		valid := err == nil && s.sessions[cookie.Value]
+		s.mu.Unlock()
+
+		if valid {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			next(w, r)
+			return
+		}
+
+		if r.Method == http.MethodPost && r.FormValue("rs") != "" {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = fmt.Fprint(w, "+:")
+			return
+		}
+
+		http.Redirect(w, r, "/consultation/index.php", http.StatusFound)
+	}
+}
+
+func (s *Gaps) handleLogin(w http.ResponseWriter, r *http.Request) {
+	message := ""
+	if r.Method == http.MethodPost {
+		s.mu.Lock()
+		valid := r.FormValue("login") == s.Fixtures.Username && r.FormValue("password") == s.Fixtures.Password
+		s.mu.Unlock()
+
+		if valid {
+			http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: s.Login(), Path: "/"})
+			s.writeStudentPage(w)
+			return
+		}
+
+		message = "Identifiant ou mot de passe incorrect"
+	}
+
+	s.writePage(w, "login", message)
+}
+
+func (s *Gaps) handleStudent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeStudentPage(w)
+		return
+	}
+
+	if r.FormValue("rs") != "smartReplacePart" {
+		writeSajaxError(w, "unknown function "+r.FormValue("rs"))
+		return
+	}
+
+	var args []any
+	if err := json.Unmarshal([]byte(r.FormValue("rsargs")), &args); err != nil || len(args) < 6 {
+		writeSajaxError(w, "invalid arguments")
+		return
+	}
+
+	yearArg, _ := args[5].(string)
+	year, _ := strconv.ParseUint(yearArg, 10, 32)
+	report, ok := s.Fixtures.Absences[uint(year)]
+	if !ok {
+		writeSajaxError(w, "no absences for year "+yearArg)
+		return
+	}
+
+	page, err := render("absences", report)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeSajax(w, "studentAbsGrid", page, "")
+}
+
+func (s *Gaps) handleGrades(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("rs") != "getStudentCCs" {
+		writeSajaxError(w, "unknown function "+r.FormValue("rs"))
+		return
+	}
+
+	var args []int
+	if err := json.Unmarshal([]byte(r.FormValue("rsargs")), &args); err != nil || len(args) != 3 {
+		writeSajaxError(w, "invalid arguments")
+		return
+	}
+
+	if uint(args[0]) != s.Fixtures.StudentId {
+		writeSajaxError(w, "access denied")
+		return
+	}
+
+	page, err := render("grades", s.Fixtures.Grades[uint(args[1])])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeSajax(w, page)
+}
+
+func (s *Gaps) handleReportCard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("id") != strconv.FormatUint(uint64(s.Fixtures.StudentId), 10) {
+		http.Error(w, "access denied", http.StatusForbidden)
+		return
+	}
+
+	page, err := renderLatin1("bulletin", s.Fixtures.ReportCard)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+	_, _ = w.Write(page)
+}
+
+func (s *Gaps) handleSchedule(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	key := Schedule{}
+	for name, target := range map[string]*uint{"annee": &key.Year, "trimestre": &key.Semester, "type": &key.Type, "id": &key.Id} {
+		value, err := strconv.ParseUint(query.Get(name), 10, 32)
+		if err != nil {
+			http.Error(w, "invalid parameter "+name, http.StatusBadRequest)
+			return
+		}
+		*target = uint(value)
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	var lessons []gaps.Lesson
+	for _, schedule := range s.Fixtures.Schedules {
+		if schedule.Year == key.Year && schedule.Semester == key.Semester && schedule.Type == key.Type && schedule.Id == key.Id {
+			lessons = append(lessons, schedule.Lessons...)
+		}
+	}
+
+	_, _ = fmt.Fprint(w, renderCalendar(lessons))
+}
+
+func (s *Gaps) writeStudentPage(w http.ResponseWriter) {
+	s.writePage(w, "student", template.JS(strconv.FormatUint(uint64(s.Fixtures.StudentId), 10)))
+}
+
+func (s *Gaps) writePage(w http.ResponseWriter, name string, data any) {
+	page, err := render(name, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprint(w, page)
+}
+
+func writeSajax(w http.ResponseWriter, parts ...string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprint(w, sajax(parts...))
+}
+
+func writeSajaxError(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprint(w, "-:"+message)
+}
@@ -1,7 +1,7 @@
 package gaps
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"lutonite.dev/gaps-cli/parser"
 	"net/url"
@@ -29,7 +29,11 @@ func NewSemesterGradesAction(config *TokenClientConfiguration, year uint, semest
 }
 
 func (a *GradesAction) FetchGrades() ([]*parser.ClassGrades, error) {
-	req, err := a.cfg.buildRequest("POST", "/consultation/controlescontinus/consultation.php")
+	return a.FetchGradesContext(context.Background())
+}
+
+func (a *GradesAction) FetchGradesContext(ctx context.Context) ([]*parser.ClassGrades, error) {
+	req, err := a.cfg.buildRequest(ctx, "POST", "/consultation/controlescontinus/consultation.php")
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +74,7 @@ func (a *GradesAction) findClass(classes []*parser.ClassGrades) ([]*parser.Class
 	}
 
 	if len(filtered) == 0 {
-		return nil, errors.New("no class found with name " + a.ClassFilter)
+		return nil, fmt.Errorf("%w with name %s", ErrClassNotFound, a.ClassFilter)
 	}
 
 	return filtered, nil
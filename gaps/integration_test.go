@@ -0,0 +1,158 @@
+package gaps_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"lutonite.dev/gaps-cli/gaps"
+	"lutonite.dev/gaps-cli/gaps/gapstest"
+)
+
+func newServer(t *testing.T) *gapstest.Server {
+	t.Helper()
+	srv := gapstest.NewServer(gapstest.DefaultFixtures())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// login logs in to the fake server the way the login command does.
+func login(t *testing.T, srv *gapstest.Server) *gaps.TokenClientConfiguration {
+	t.Helper()
+	cfg := new(gaps.ClientConfiguration)
+	cfg.Init(srv.URL)
+
+	action := gaps.NewLoginAction(cfg, srv.Fixtures.Username, srv.Fixtures.Password)
+	token, err := action.FetchTokenContext(context.Background())
+	if err != nil {
+		t.Fatalf("FetchTokenContext: %v", err)
+	}
+
+	studentId, err := action.FetchStudentIdContext(context.Background(), token)
+	if err != nil {
+		t.Fatalf("FetchStudentIdContext: %v", err)
+	}
+
+	tc := new(gaps.TokenClientConfiguration)
+	if err := tc.InitToken(srv.URL, token, studentId); err != nil {
+		t.Fatalf("InitToken: %v", err)
+	}
+
+	return tc
+}
+
+func TestLogin(t *testing.T) {
+	srv := newServer(t)
+	cfg := new(gaps.ClientConfiguration)
+	cfg.Init(srv.URL)
+
+	action := gaps.NewLoginAction(cfg, "jane.doe", "hunter2")
+	token, err := action.FetchTokenContext(context.Background())
+	if err != nil {
+		t.Fatalf("FetchTokenContext: %v", err)
+	}
+	if token == "" {
+		t.Fatal("FetchTokenContext returned an empty token")
+	}
+
+	studentId, err := action.FetchStudentIdContext(context.Background(), token)
+	if err != nil {
+		t.Fatalf("FetchStudentIdContext: %v", err)
+	}
+	if studentId != srv.Fixtures.StudentId {
+		t.Errorf("student id = %d, want %d", studentId, srv.Fixtures.StudentId)
+	}
+}
+
+func TestLoginInvalidCredentials(t *testing.T) {
+	srv := newServer(t)
+	cfg := new(gaps.ClientConfiguration)
+	cfg.Init(srv.URL)
+
+	_, err := gaps.NewLoginAction(cfg, "jane.doe", "wrong").FetchTokenContext(context.Background())
+	if !errors.Is(err, gaps.ErrInvalidCredentials) {
+		t.Fatalf("FetchTokenContext error = %v, want %v", err, gaps.ErrInvalidCredentials)
+	}
+}
+
+func TestFetchGrades(t *testing.T) {
+	srv := newServer(t)
+	cfg := login(t, srv)
+
+	classes, err := gaps.NewGradesAction(cfg, 2023).FetchGradesContext(context.Background())
+	if err != nil {
+		t.Fatalf("FetchGradesContext: %v", err)
+	}
+
+	want := srv.Fixtures.Grades[2023]
+	if len(classes) != len(want) {
+		t.Fatalf("got %d classes, want %d", len(classes), len(want))
+	}
+	for i, class := range classes {
+		if class.Name != want[i].Name || class.GlobalMean != want[i].GlobalMean {
+			t.Errorf("class %d = %s (%s), want %s (%s)", i, class.Name, class.GlobalMean, want[i].Name, want[i].GlobalMean)
+		}
+		if len(class.GradeGroups) != len(want[i].GradeGroups) {
+			t.Errorf("class %s has %d groups, want %d", class.Name, len(class.GradeGroups), len(want[i].GradeGroups))
+		}
+	}
+
+	grade := classes[0].GradeGroups[0].Grades[0]
+	if value, ok := grade.Value.Float(); !ok || value != 4 {
+		t.Errorf("grade value = %v (%t), want 4", value, ok)
+	}
+}
+
+func TestSessionExpired(t *testing.T) {
+	srv := newServer(t)
+	cfg := login(t, srv)
+	srv.ExpireSessions()
+
+	_, err := gaps.NewGradesAction(cfg, 2023).FetchGradesContext(context.Background())
+	if !errors.Is(err, gaps.ErrSessionExpired) {
+		t.Fatalf("FetchGradesContext error = %v, want %v", err, gaps.ErrSessionExpired)
+	}
+}
+
+func TestSessionExpiredRelogin(t *testing.T) {
+	srv := newServer(t)
+	cfg := login(t, srv)
+
+	var refreshed []string
+	cfg.SetCredentialProvider(gaps.StaticCredentials{Username: srv.Fixtures.Username, Password: srv.Fixtures.Password})
+	cfg.OnTokenRefresh(func(token string) {
+		refreshed = append(refreshed, token)
+	})
+
+	srv.ExpireSessions()
+	classes, err := gaps.NewGradesAction(cfg, 2023).FetchGradesContext(context.Background())
+	if err != nil {
+		t.Fatalf("FetchGradesContext: %v", err)
+	}
+	if len(classes) != len(srv.Fixtures.Grades[2023]) {
+		t.Errorf("got %d classes, want %d", len(classes), len(srv.Fixtures.Grades[2023]))
+	}
+	if len(refreshed) != 1 || refreshed[0] == "" {
+		t.Errorf("OnTokenRefresh called with %q, want a single new token", refreshed)
+	}
+
+	// the new session is kept for the following requests
+	if _, err := gaps.NewReportCardAction(cfg).FetchReportCardContext(context.Background()); err != nil {
+		t.Fatalf("FetchReportCardContext: %v", err)
+	}
+	if len(refreshed) != 1 {
+		t.Errorf("logged in %d times, want 1", len(refreshed))
+	}
+}
+
+func TestSessionExpiredInvalidCredentials(t *testing.T) {
+	srv := newServer(t)
+	cfg := login(t, srv)
+	cfg.SetCredentialProvider(gaps.StaticCredentials{Username: srv.Fixtures.Username, Password: "changed"})
+
+	srv.ExpireSessions()
+	_, err := gaps.NewGradesAction(cfg, 2023).FetchGradesContext(context.Background())
+	if !errors.Is(err, gaps.ErrInvalidCredentials) {
+		t.Fatalf("FetchGradesContext error = %v, want %v", err, gaps.ErrInvalidCredentials)
+	}
+}
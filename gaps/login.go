@@ -1,9 +1,11 @@
 package gaps
 
 import (
-	"errors"
+	"context"
 	"lutonite.dev/gaps-cli/parser"
+	"net/http"
 	"net/url"
+	"strings"
 )
 
 type LoginAction struct {
@@ -22,16 +24,30 @@ func NewLoginAction(config *ClientConfiguration, username string, password strin
 }
 
 func (a *LoginAction) FetchToken() (string, error) {
-	res, err := a.cfg.client.PostForm(a.cfg.baseUrl+"/consultation/index.php", url.Values{
+	return a.FetchTokenContext(context.Background())
+}
+
+func (a *LoginAction) FetchTokenContext(ctx context.Context) (string, error) {
+	data := url.Values{
 		"login":    {a.username},
 		"password": {a.password},
 		"submit":   {"Enter"},
-	})
+	}
 
+	req, err := http.NewRequestWithContext(ctx, "POST", a.cfg.baseUrl+"/consultation/index.php", strings.NewReader(data.Encode()))
 	if err != nil {
 		return "", err
 	}
 
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	a.cfg.setUserAgent(req)
+
+	res, err := a.cfg.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
 	var value = ""
 	for _, cookie := range res.Cookies() {
 		if cookie.Name == "GAPSSESSID" {
@@ -43,14 +59,22 @@ func (a *LoginAction) FetchToken() (string, error) {
 		return value, nil
 	}
 
-	return "", errors.New("no token found in response")
+	return "", ErrInvalidCredentials
 }
 
 func (a *LoginAction) FetchStudentId(token string) (uint, error) {
+	return a.FetchStudentIdContext(context.Background(), token)
+}
+
+func (a *LoginAction) FetchStudentIdContext(ctx context.Context, token string) (uint, error) {
 	tc := a.cfg.SetToken(token)
 
-	req, err := tc.buildRequest("GET", "/consultation/etudiant/")
-	res, err := tc.client.Do(req)
+	req, err := tc.buildRequest(ctx, "GET", "/consultation/etudiant/")
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := tc.doGet(req)
 	if err != nil {
 		return 0, err
 	}
@@ -0,0 +1,37 @@
+package gaps
+
+import (
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimitedTransport delays requests so that every client sharing it stays below a request rate towards GAPS.
+type RateLimitedTransport struct {
+	next    http.RoundTripper
+	limiter *rate.Limiter
+}
+
+// NewRateLimitedTransport allows perSecond requests per second with bursts of burst requests, on top of next or
+// of the default transport when nil.
+func NewRateLimitedTransport(next http.RoundTripper, perSecond float64, burst int) *RateLimitedTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimitedTransport{
+		next:    next,
+		limiter: rate.NewLimiter(rate.Limit(perSecond), burst),
+	}
+}
+
+func (t *RateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	return t.next.RoundTrip(req)
+}
@@ -1,6 +1,7 @@
 package gaps
 
 import (
+	"context"
 	"fmt"
 	"golang.org/x/net/html/charset"
 	"lutonite.dev/gaps-cli/parser"
@@ -17,12 +18,16 @@ func NewReportCardAction(config *TokenClientConfiguration) *ReportCardAction {
 }
 
 func (a *ReportCardAction) FetchReportCard() ([]*parser.ModuleReport, error) {
-	req, err := a.cfg.buildRequest("GET", fmt.Sprintf("/consultation/notes/bulletin.php?id=%d", a.cfg.studentId))
+	return a.FetchReportCardContext(context.Background())
+}
+
+func (a *ReportCardAction) FetchReportCardContext(ctx context.Context) ([]*parser.ModuleReport, error) {
+	req, err := a.cfg.buildRequest(ctx, "GET", fmt.Sprintf("/consultation/notes/bulletin.php?id=%d", a.cfg.studentId))
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := a.cfg.doForm(req, nil)
+	res, err := a.cfg.doGet(req)
 	if err != nil {
 		return nil, err
 	}
@@ -1,58 +1,84 @@
 package gaps
 
 import (
+	"context"
 	"fmt"
 	"github.com/arran4/golang-ical"
+	"net/url"
+	"sort"
+	"time"
 )
 
+type ScheduleType uint
+
+const (
+	TeacherSchedule ScheduleType = 1
+	StudentSchedule ScheduleType = 2
+	RoomSchedule    ScheduleType = 4
+)
+
+func (t ScheduleType) String() string {
+	switch t {
+	case TeacherSchedule:
+		return "teacher"
+	case StudentSchedule:
+		return "student"
+	case RoomSchedule:
+		return "room"
+	default:
+		return fmt.Sprintf("type %d", uint(t))
+	}
+}
+
 type ScheduleAction struct {
 	cfg       *TokenClientConfiguration
 	year      uint
 	semester  uint
-	schedType uint
+	schedType ScheduleType
 	targetId  uint
 }
 
-func NewStudentScheduleAction(config *TokenClientConfiguration, year uint, semester uint) *ScheduleAction {
+// NewScheduleAction fetches the calendar of any kind of target, a zero target id means the logged-in student.
+func NewScheduleAction(config *TokenClientConfiguration, year uint, semester uint, schedType ScheduleType, targetId uint) *ScheduleAction {
+	if targetId == 0 && schedType == StudentSchedule {
+		targetId = config.studentId
+	}
+
 	return &ScheduleAction{
 		cfg:       config,
 		year:      year,
 		semester:  semester,
-		schedType: 2,
-		targetId:  config.studentId,
+		schedType: schedType,
+		targetId:  targetId,
 	}
 }
 
+func NewStudentScheduleAction(config *TokenClientConfiguration, year uint, semester uint) *ScheduleAction {
+	return NewScheduleAction(config, year, semester, StudentSchedule, config.studentId)
+}
+
 func NewTeacherScheduleAction(config *TokenClientConfiguration, year uint, semester uint, teacher uint) *ScheduleAction {
-	return &ScheduleAction{
-		cfg:       config,
-		year:      year,
-		semester:  semester,
-		schedType: 1,
-		targetId:  teacher,
-	}
+	return NewScheduleAction(config, year, semester, TeacherSchedule, teacher)
 }
 
 func NewRoomScheduleAction(config *TokenClientConfiguration, year uint, semester uint, room uint) *ScheduleAction {
-	return &ScheduleAction{
-		cfg:       config,
-		year:      year,
-		semester:  semester,
-		schedType: 4,
-		targetId:  room,
-	}
+	return NewScheduleAction(config, year, semester, RoomSchedule, room)
 }
 
 func (a *ScheduleAction) FetchSchedule() (*ics.Calendar, error) {
-	req, err := a.cfg.buildRequest("POST", fmt.Sprintf(
+	return a.FetchScheduleContext(context.Background())
+}
+
+func (a *ScheduleAction) FetchScheduleContext(ctx context.Context) (*ics.Calendar, error) {
+	req, err := a.cfg.buildRequest(ctx, "POST", fmt.Sprintf(
 		"/consultation/horaires/?annee=%d&trimestre=%d&type=%d&id=%d&icalendarversion=2&individual=1",
-		a.year, a.semester, a.schedType, a.targetId,
+		a.year, a.semester, uint(a.schedType), a.targetId,
 	))
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := a.cfg.doForm(req, nil)
+	res, err := a.cfg.doForm(req, url.Values{})
 	if err != nil {
 		return nil, err
 	}
@@ -60,3 +86,92 @@ func (a *ScheduleAction) FetchSchedule() (*ics.Calendar, error) {
 	defer res.Body.Close()
 	return ics.ParseCalendar(res.Body)
 }
+
+// MergeCalendars gathers the events of several calendars, e.g. of every term, into a single one.
+// Events sharing the same UID are only kept once.
+func MergeCalendars(calendars ...*ics.Calendar) *ics.Calendar {
+	merged := ics.NewCalendarFor("gaps-cli")
+	merged.SetMethod(ics.MethodPublish)
+	seen := make(map[string]bool)
+	for _, calendar := range calendars {
+		if calendar == nil {
+			continue
+		}
+
+		for _, event := range calendar.Events() {
+			if id := event.Id(); id != "" {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+			}
+
+			merged.AddVEvent(event)
+		}
+	}
+
+	return merged
+}
+
+// Lesson is the flattened content of a calendar event.
+type Lesson struct {
+	Uid      string    `json:"uid"`
+	Summary  string    `json:"summary"`
+	Location string    `json:"location"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+}
+
+// Lessons extracts the events of a calendar sorted by start time, events without valid dates are skipped.
+func Lessons(calendar *ics.Calendar) []*Lesson {
+	var lessons []*Lesson
+	for _, event := range calendar.Events() {
+		start, err := event.GetStartAt()
+		if err != nil {
+			continue
+		}
+
+		end, err := event.GetEndAt()
+		if err != nil {
+			end = start
+		}
+
+		lessons = append(lessons, &Lesson{
+			Uid:      event.Id(),
+			Summary:  propertyValue(event, ics.ComponentPropertySummary),
+			Location: propertyValue(event, ics.ComponentPropertyLocation),
+			Start:    start,
+			End:      end,
+		})
+	}
+
+	sort.SliceStable(lessons, func(i, j int) bool {
+		return lessons[i].Start.Before(lessons[j].Start)
+	})
+
+	return lessons
+}
+
+// FilterCalendar returns a calendar with the events starting in [from, to), zero bounds are open.
+func FilterCalendar(calendar *ics.Calendar, from time.Time, to time.Time) *ics.Calendar {
+	filtered := ics.NewCalendarFor("gaps-cli")
+	filtered.SetMethod(ics.MethodPublish)
+	for _, event := range calendar.Events() {
+		start, err := event.GetStartAt()
+		if err != nil || (!from.IsZero() && start.Before(from)) || (!to.IsZero() && !start.Before(to)) {
+			continue
+		}
+
+		filtered.AddVEvent(event)
+	}
+
+	return filtered
+}
+
+func propertyValue(event *ics.VEvent, property ics.ComponentProperty) string {
+	if p := event.GetProperty(property); p != nil {
+		return p.Value
+	}
+
+	return ""
+}
@@ -31,6 +31,18 @@ func (s Semester) Type() string {
 	return "Semester"
 }
 
+// ScheduleTerms returns the GAPS schedule terms (trimestre) covering the semester.
+func (s Semester) ScheduleTerms() []uint {
+	switch s {
+	case First:
+		return []uint{0}
+	case Second:
+		return []uint{1}
+	default:
+		return []uint{0, 1, 3}
+	}
+}
+
 func (s Semester) rsArg() int {
 	switch s {
 	case First:
@@ -0,0 +1,156 @@
+package gaps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CredentialProvider supplies the credentials used to transparently log in again when GAPS
+// expires the session before the client is done with it.
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (username string, password string, err error)
+}
+
+// CredentialProviderFunc adapts a function to the CredentialProvider interface.
+type CredentialProviderFunc func(ctx context.Context) (string, string, error)
+
+func (f CredentialProviderFunc) Credentials(ctx context.Context) (string, string, error) {
+	return f(ctx)
+}
+
+// StaticCredentials is a CredentialProvider always returning the same username and password.
+type StaticCredentials struct {
+	Username string
+	Password string
+}
+
+func (c StaticCredentials) Credentials(context.Context) (string, string, error) {
+	return c.Username, c.Password, nil
+}
+
+var (
+	loginFormRegex = regexp.MustCompile(`(?i)<input[^>]+name=["']?password["'\s>]`)
+	loginPaths     = map[string]bool{"/": true, "/consultation/": true, "/consultation/index.php": true}
+)
+
+// SetCredentialProvider enables transparent re-authentication when the session expires,
+// the request that noticed the expiry is then replayed once with the new token.
+func (tc *TokenClientConfiguration) SetCredentialProvider(provider CredentialProvider) {
+	tc.credentials = provider
+}
+
+// OnTokenRefresh registers a callback invoked with the new token after each re-authentication,
+// allowing callers to persist it.
+func (tc *TokenClientConfiguration) OnTokenRefresh(fn func(token string)) {
+	tc.onTokenRefresh = fn
+}
+
+// doGet sends a request without a body, logging in again when the session expired.
+func (tc *TokenClientConfiguration) doGet(req *http.Request) (*http.Response, error) {
+	return tc.doForm(req, nil)
+}
+
+// doForm sends a form, logging in again when the session expired. A nil form sends no body at all.
+func (tc *TokenClientConfiguration) doForm(req *http.Request, data url.Values) (*http.Response, error) {
+	res, err := tc.sendForm(req, data)
+	if !errors.Is(err, ErrSessionExpired) || tc.credentials == nil {
+		return res, err
+	}
+
+	log.Info("GAPS session expired, logging in again")
+	previousToken := tc.token
+	if err := tc.relogin(req.Context()); err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	retry.Header.Del("Cookie")
+	tc.addAuthCookie(retry)
+
+	if data == nil {
+		return tc.sendForm(retry, nil)
+	}
+
+	// some sajax calls embed the session token in their arguments
+	replayData := url.Values{}
+	for key, values := range data {
+		for _, value := range values {
+			replayData.Add(key, strings.ReplaceAll(value, previousToken, tc.token))
+		}
+	}
+
+	return tc.sendForm(retry, replayData)
+}
+
+func (tc *TokenClientConfiguration) sendForm(req *http.Request, data url.Values) (*http.Response, error) {
+	if data != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Body = io.NopCloser(strings.NewReader(data.Encode()))
+	}
+
+	res, err := tc.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if isSessionExpired(req, res, body, data) {
+		return nil, ErrSessionExpired
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	return res, nil
+}
+
+func (tc *TokenClientConfiguration) relogin(ctx context.Context) error {
+	username, password, err := tc.credentials.Credentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	token, err := NewLoginAction(&tc.ClientConfiguration, username, password).FetchTokenContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	tc.token = token
+	if tc.onTokenRefresh != nil {
+		tc.onTokenRefresh(token)
+	}
+
+	return nil
+}
+
+// isSessionExpired detects the ways GAPS answers to a request made with a dead session: redirecting
+// to the login page, rendering the login form in place of the page, or an empty sajax reply.
+func isSessionExpired(req *http.Request, res *http.Response, body []byte, data url.Values) bool {
+	if res.Request != nil && res.Request.URL.Path != req.URL.Path && loginPaths[res.Request.URL.Path] {
+		return true
+	}
+
+	if loginFormRegex.Match(body) {
+		return true
+	}
+
+	if data.Get("rs") != "" {
+		switch string(bytes.TrimSpace(body)) {
+		case "", "+:", `+:""`:
+			return true
+		}
+	}
+
+	return false
+}
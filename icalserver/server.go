@@ -0,0 +1,307 @@
+// Package icalserver serves GAPS schedules as iCal subscriptions, refreshing them in the background so calendar
+// applications never need to log in to GAPS themselves.
+package icalserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ics "github.com/arran4/golang-ical"
+	log "github.com/sirupsen/logrus"
+	"lutonite.dev/gaps-cli/gaps"
+)
+
+// Target identifies a schedule, a zero id for a student schedule means the logged-in student.
+type Target struct {
+	Type gaps.ScheduleType
+	Id   uint
+}
+
+// Path returns the stable URL path the target is served on.
+func (t Target) Path() string {
+	if t.Type == gaps.StudentSchedule && t.Id == 0 {
+		return "/student.ics"
+	}
+
+	return fmt.Sprintf("/%s/%d.ics", t.Type, t.Id)
+}
+
+func (t Target) String() string {
+	return strings.TrimSuffix(strings.TrimPrefix(t.Path(), "/"), ".ics")
+}
+
+// ParseTarget parses a path as returned by Target.Path.
+func ParseTarget(path string) (Target, bool) {
+	if path == "/student.ics" {
+		return Target{Type: gaps.StudentSchedule}, true
+	}
+
+	kind, file, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !ok || !strings.HasSuffix(file, ".ics") {
+		return Target{}, false
+	}
+
+	id, err := strconv.ParseUint(strings.TrimSuffix(file, ".ics"), 10, 32)
+	if err != nil || id == 0 {
+		return Target{}, false
+	}
+
+	for _, t := range []gaps.ScheduleType{gaps.StudentSchedule, gaps.TeacherSchedule, gaps.RoomSchedule} {
+		if kind == t.String() {
+			return Target{Type: t, Id: uint(id)}, true
+		}
+	}
+
+	return Target{}, false
+}
+
+// FetchFunc retrieves the current calendar of a target from GAPS.
+type FetchFunc func(ctx context.Context, target Target) (*ics.Calendar, error)
+
+type entry struct {
+	body     []byte
+	etag     string
+	modified time.Time
+	fetched  time.Time
+	err      error
+}
+
+// AdHocTTL is how long a target that was not preloaded stays cached and refreshed after its last request.
+const AdHocTTL = 24 * time.Hour
+
+// Server caches the calendars of the preloaded targets and serves them over HTTP. Other targets are only served
+// when a token is required, and they are forgotten when not requested anymore.
+type Server struct {
+	fetch    FetchFunc
+	token    string
+	interval time.Duration
+
+	mu      sync.Mutex
+	cache   map[Target]*entry
+	pinned  map[Target]bool
+	adHocAt map[Target]time.Time
+	// fetching serializes the calls to GAPS, so a burst of subscriptions doesn't turn into a burst of requests
+	fetching sync.Mutex
+}
+
+// New returns a server refreshing its calendars every interval, which must be positive. Requests must carry
+// token unless it is empty, in which case only the preloaded targets are served.
+func New(fetch FetchFunc, interval time.Duration, token string) *Server {
+	return &Server{
+		fetch:    fetch,
+		token:    token,
+		interval: interval,
+		cache:    make(map[Target]*entry),
+		pinned:   make(map[Target]bool),
+		adHocAt:  make(map[Target]time.Time),
+	}
+}
+
+// Preload fetches the given targets so they are served from the cache from the start, and refreshed for as long
+// as the server runs.
+func (s *Server) Preload(ctx context.Context, targets ...Target) {
+	s.mu.Lock()
+	for _, target := range targets {
+		s.pinned[target] = true
+	}
+	s.mu.Unlock()
+
+	for _, target := range targets {
+		s.refresh(ctx, target)
+	}
+}
+
+// Run refreshes every calendar on the configured interval until the context is done.
+func (s *Server) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.evict()
+			for _, target := range s.targets() {
+				s.refresh(ctx, target)
+			}
+		}
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	target, ok := ParseTarget(r.URL.Path)
+	if !ok || !s.serves(target) {
+		http.NotFound(w, r)
+		return
+	}
+
+	e := s.lookup(target)
+	if e == nil || e.body == nil {
+		e = s.refresh(r.Context(), target)
+	}
+
+	if e.body == nil {
+		log.WithError(e.err).Warnf("No calendar available for %s", target)
+		http.Error(w, "couldn't fetch the schedule from GAPS", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("ETag", e.etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(s.interval.Seconds())))
+	http.ServeContent(w, r, "", e.modified, bytes.NewReader(e.body))
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+
+	provided := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		provided = bearer
+	}
+
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(s.token)) == 1
+}
+
+// serves tells whether the target is served, remembering when ad-hoc targets are requested.
+func (s *Server) serves(target Target) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pinned[target] {
+		return true
+	}
+	// without a token, anyone reaching the server could pull any schedule with the session of the user
+	if s.token == "" {
+		return false
+	}
+
+	s.adHocAt[target] = time.Now()
+	return true
+}
+
+// evict forgets the ad-hoc targets that were not requested for AdHocTTL.
+func (s *Server) evict() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for target, requested := range s.adHocAt {
+		if time.Since(requested) > AdHocTTL {
+			delete(s.adHocAt, target)
+			delete(s.cache, target)
+		}
+	}
+}
+
+func (s *Server) lookup(target Target) *entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache[target]
+}
+
+// targets returns the preloaded targets, along with the ad-hoc ones having a calendar.
+func (s *Server) targets() []Target {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	targets := make([]Target, 0, len(s.pinned)+len(s.cache))
+	for target := range s.pinned {
+		targets = append(targets, target)
+	}
+	for target := range s.cache {
+		if !s.pinned[target] {
+			targets = append(targets, target)
+		}
+	}
+
+	return targets
+}
+
+// refresh fetches the calendar of a target and updates its cache entry. The previous calendar is kept when
+// the fetch fails, and the modification time only moves forward when the content actually changed. Failed
+// first fetches are not cached, so unknown targets don't pile up.
+func (s *Server) refresh(ctx context.Context, target Target) *entry {
+	s.fetching.Lock()
+	defer s.fetching.Unlock()
+
+	// another request may have fetched the target while this one was waiting
+	if e := s.lookup(target); e != nil && time.Since(e.fetched) < time.Minute {
+		return e
+	}
+
+	var body []byte
+	calendar, err := s.fetch(ctx, target)
+	if err == nil {
+		var buf bytes.Buffer
+		if err = calendar.SerializeTo(&buf); err == nil {
+			body = buf.Bytes()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.cache[target]
+	if err != nil {
+		log.WithError(err).Errorf("Failed to refresh the %s schedule", target)
+		e := &entry{fetched: time.Now(), err: err}
+		if previous == nil {
+			return e
+		}
+
+		e.body, e.etag, e.modified = previous.body, previous.etag, previous.modified
+		s.cache[target] = e
+		return e
+	}
+
+	e := &entry{
+		body:     body,
+		etag:     etag(body),
+		modified: time.Now().UTC().Truncate(time.Second),
+		fetched:  time.Now(),
+	}
+	if previous != nil && previous.etag == e.etag {
+		e.body, e.modified = previous.body, previous.modified
+	} else {
+		log.Infof("Refreshed the %s schedule", target)
+	}
+
+	s.cache[target] = e
+	return e
+}
+
+// etag hashes a serialized calendar, ignoring the DTSTAMP properties GAPS sets to the generation time so an
+// unchanged schedule keeps the same tag across refreshes.
+func etag(body []byte) string {
+	h := sha256.New()
+	for _, line := range bytes.SplitAfter(body, []byte("\n")) {
+		if !bytes.HasPrefix(line, []byte("DTSTAMP")) {
+			h.Write(line)
+		}
+	}
+
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
@@ -0,0 +1,85 @@
+package icalserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ics "github.com/arran4/golang-ical"
+	"lutonite.dev/gaps-cli/gaps"
+)
+
+// fakeFetch serves an empty calendar for every target but the room 404, counting the fetches.
+func fakeFetch(fetches *int) FetchFunc {
+	return func(ctx context.Context, target Target) (*ics.Calendar, error) {
+		*fetches++
+		if target.Type == gaps.RoomSchedule && target.Id == 404 {
+			return nil, errors.New("unknown room")
+		}
+
+		return ics.NewCalendar(), nil
+	}
+}
+
+func get(s *Server, path string) int {
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Code
+}
+
+func TestServerWithoutToken(t *testing.T) {
+	fetches := 0
+	s := New(fakeFetch(&fetches), time.Hour, "")
+	s.Preload(context.Background(), Target{Type: gaps.StudentSchedule}, Target{Type: gaps.TeacherSchedule, Id: 12})
+
+	tests := []struct {
+		path string
+		code int
+	}{
+		{"/student.ics", http.StatusOK},
+		{"/teacher/12.ics", http.StatusOK},
+		{"/teacher/13.ics", http.StatusNotFound},
+		{"/student/4242.ics", http.StatusNotFound},
+		{"/unknown", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		if code := get(s, tt.path); code != tt.code {
+			t.Errorf("GET %s = %d, want %d", tt.path, code, tt.code)
+		}
+	}
+	if fetches != 2 {
+		t.Errorf("fetched %d calendars, want only the 2 preloaded ones", fetches)
+	}
+}
+
+func TestServerWithToken(t *testing.T) {
+	fetches := 0
+	s := New(fakeFetch(&fetches), time.Hour, "secret")
+
+	if code := get(s, "/room/1.ics"); code != http.StatusUnauthorized {
+		t.Errorf("GET without token = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := get(s, "/room/1.ics?token=secret"); code != http.StatusOK {
+		t.Errorf("GET with token = %d, want %d", code, http.StatusOK)
+	}
+	if code := get(s, "/room/404.ics?token=secret"); code != http.StatusBadGateway {
+		t.Errorf("GET failing target = %d, want %d", code, http.StatusBadGateway)
+	}
+
+	// the failed first fetch is not cached nor refreshed
+	targets := s.targets()
+	if len(targets) != 1 || targets[0] != (Target{Type: gaps.RoomSchedule, Id: 1}) {
+		t.Errorf("refreshed targets = %v, want only room/1", targets)
+	}
+
+	// ad-hoc targets are forgotten once not requested anymore
+	s.adHocAt[Target{Type: gaps.RoomSchedule, Id: 1}] = time.Now().Add(-AdHocTTL - time.Minute)
+	s.evict()
+	if targets := s.targets(); len(targets) != 0 {
+		t.Errorf("refreshed targets after eviction = %v, want none", targets)
+	}
+}
@@ -0,0 +1,105 @@
+// Package metrics exposes the health and Prometheus metrics of long-running gaps-cli commands.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "gaps_cli"
+
+var (
+	// Registry holds every gaps-cli metric along with the Go runtime and process ones.
+	Registry = prometheus.NewRegistry()
+
+	ScrapeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "scrape_duration_seconds",
+		Help:      "Duration of the scraper runs.",
+		Buckets:   []float64{1, 2.5, 5, 10, 20, 30, 60, 120},
+	}, []string{"account", "result"})
+
+	LastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "scrape_last_success_timestamp_seconds",
+		Help:      "Time of the last successful scraper run.",
+	}, []string{"account"})
+
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "gaps_request_duration_seconds",
+		Help:      "Latency of the requests to GAPS by path and status code, the code is 0 for network errors.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "path", "code"})
+
+	ParseFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "parse_failures_total",
+		Help:      "Number of scraper runs that failed because GAPS returned an unexpected page structure.",
+	}, []string{"account"})
+
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Number of logins to GAPS, including the refreshes of expired sessions.",
+	}, []string{"account", "result"})
+
+	Notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Number of notifications by notifier and result.",
+	}, []string{"account", "notifier", "type", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		ScrapeDuration,
+		LastSuccess,
+		RequestDuration,
+		ParseFailures,
+		Logins,
+		Notifications,
+	)
+}
+
+// Result returns the value of the result label for an operation outcome.
+func Result(err error) string {
+	if err != nil {
+		return "failure"
+	}
+
+	return "success"
+}
+
+// InstrumentedTransport records the latency and status of the requests it forwards in RequestDuration.
+type InstrumentedTransport struct {
+	next http.RoundTripper
+}
+
+// InstrumentTransport wraps next, or the default transport when nil.
+func InstrumentTransport(next http.RoundTripper) *InstrumentedTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &InstrumentedTransport{next: next}
+}
+
+func (t *InstrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+
+	code := 0
+	if err == nil {
+		code = res.StatusCode
+	}
+	RequestDuration.WithLabelValues(req.Method, req.URL.Path, strconv.Itoa(code)).Observe(time.Since(start).Seconds())
+
+	return res, err
+}
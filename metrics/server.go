@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Health tracks the last successful run of each scraped account to answer readiness probes.
+type Health struct {
+	maxAge time.Duration
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// NewHealth returns a tracker considering the accounts stale when they had no successful run for maxAge.
+func NewHealth(maxAge time.Duration, accounts ...string) *Health {
+	h := &Health{
+		maxAge: maxAge,
+		last:   make(map[string]time.Time),
+	}
+	for _, account := range accounts {
+		h.last[account] = time.Time{}
+	}
+
+	return h
+}
+
+// Success records a successful run of an account.
+func (h *Health) Success(account string) {
+	now := time.Now()
+	LastSuccess.WithLabelValues(account).Set(float64(now.Unix()))
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last[account] = now
+}
+
+type accountStatus struct {
+	Account     string     `json:"account"`
+	Ready       bool       `json:"ready"`
+	LastSuccess *time.Time `json:"lastSuccess"`
+	Age         string     `json:"age,omitempty"`
+}
+
+// status reports whether every account had a successful run recently.
+func (h *Health) status() (bool, []accountStatus) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ready := true
+	var statuses []accountStatus
+	for account, last := range h.last {
+		status := accountStatus{Account: account}
+		if !last.IsZero() {
+			last := last
+			status.LastSuccess = &last
+			status.Age = time.Since(last).Round(time.Second).String()
+			status.Ready = time.Since(last) <= h.maxAge
+		}
+
+		ready = ready && status.Ready
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Account < statuses[j].Account
+	})
+
+	return ready, statuses
+}
+
+// Handler serves /healthz, /readyz and the Prometheus /metrics.
+func Handler(health *Health) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		ready, statuses := health.status()
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"ready": ready, "accounts": statuses})
+	})
+	mux.Handle("/metrics", promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry}))
+
+	return mux
+}
@@ -11,6 +11,10 @@ import (
 type Client struct {
 	BaseUrl string
 	ApiKey  string
+	// Version of the API contract, 1 only sends grades as an ApiGrade while 2 sends every event as a Payload.
+	Version int
+	// Secret signs the requests when set, see Sign.
+	Secret string
 
 	httpClient *http.Client
 }
@@ -24,6 +28,7 @@ func NewClient(apiUrl string, apiKey string) *Client {
 	return &Client{
 		BaseUrl: apiUrl,
 		ApiKey:  apiKey,
+		Version: 1,
 
 		httpClient: &http.Client{
 			Timeout: time.Minute,
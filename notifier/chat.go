@@ -0,0 +1,52 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+)
+
+// chat posts the rendered message to a Discord or Slack incoming webhook, which only differ by their payload.
+type chat struct {
+	name    string
+	url     string
+	tmpl    *Template
+	payload func(msg Message) any
+}
+
+func newDiscord(cfg Config, tmpl *Template) (Notifier, error) {
+	if err := requireUrl(cfg); err != nil {
+		return nil, err
+	}
+
+	return &chat{name: cfg.Name, url: cfg.Url, tmpl: tmpl, payload: func(msg Message) any {
+		return map[string]string{"content": fmt.Sprintf("**%s**\n%s", msg.Title, msg.Body)}
+	}}, nil
+}
+
+func newSlack(cfg Config, tmpl *Template) (Notifier, error) {
+	if err := requireUrl(cfg); err != nil {
+		return nil, err
+	}
+
+	return &chat{name: cfg.Name, url: cfg.Url, tmpl: tmpl, payload: func(msg Message) any {
+		return map[string]string{"text": fmt.Sprintf("*%s*\n%s", msg.Title, msg.Body)}
+	}}, nil
+}
+
+func (c *chat) Name() string {
+	return c.name
+}
+
+func (c *chat) Notify(ctx context.Context, event *Event) error {
+	msg, err := c.tmpl.Render(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := newJsonRequest(ctx, c.url, c.payload(msg))
+	if err != nil {
+		return err
+	}
+
+	return post(req)
+}
@@ -0,0 +1,171 @@
+package notifier
+
+import (
+	"fmt"
+	"os"
+)
+
+// Config is the configuration of a single notifier, as found in the scraper.notifiers list.
+type Config struct {
+	// Type is one of api, webhook, discord, slack, smtp, ntfy, gotify or stdout.
+	Type string `mapstructure:"type"`
+	// Name identifies the notifier in logs, defaults to its type.
+	Name string `mapstructure:"name"`
+	// Events restricts the forwarded event types, all events are forwarded when empty.
+	Events []EventType `mapstructure:"events"`
+
+	// Title and Template are text/template sources rendered with the Event, see DefaultTemplates. The fields of
+	// the Event depending on its type, they apply to the types listed in Events and require it to be set.
+	Title    string `mapstructure:"title"`
+	Template string `mapstructure:"template"`
+	// Templates overrides the title and template of each event type.
+	Templates map[EventType]MessageTemplate `mapstructure:"templates"`
+
+	Url      string            `mapstructure:"url"`
+	Token    string            `mapstructure:"token"`
+	Headers  map[string]string `mapstructure:"headers"`
+	Priority int               `mapstructure:"priority"`
+	Tags     []string          `mapstructure:"tags"`
+	// Secret signs the requests of the api and webhook notifiers with HMAC-SHA256, see Sign.
+	Secret string `mapstructure:"secret"`
+	// Version of the contract of the api notifier, 1 (the default) or 2, see PayloadVersion.
+	Version int `mapstructure:"version"`
+	// Format of the stdout notifier, either text or json.
+	Format string `mapstructure:"format"`
+
+	Smtp SmtpConfig `mapstructure:"smtp"`
+}
+
+type SmtpConfig struct {
+	Host     string   `mapstructure:"host"`
+	Port     int      `mapstructure:"port"`
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+	From     string   `mapstructure:"from"`
+	To       []string `mapstructure:"to"`
+}
+
+// New builds the notifier described by the configuration.
+func New(cfg Config) (Notifier, error) {
+	if cfg.Name == "" {
+		cfg.Name = cfg.Type
+	}
+
+	if (cfg.Title != "" || cfg.Template != "") && len(cfg.Events) == 0 {
+		return nil, fmt.Errorf("notifier %s: title and template need the events they apply to, or set templates per event type", cfg.Name)
+	}
+
+	tmpl, err := NewTemplate(cfg.templates())
+	if err != nil {
+		return nil, fmt.Errorf("notifier %s: %w", cfg.Name, err)
+	}
+
+	var n Notifier
+	switch cfg.Type {
+	case "api":
+		if cfg.Url == "" {
+			return nil, fmt.Errorf("notifier %s: missing url", cfg.Name)
+		}
+		if cfg.Version < 0 || cfg.Version > PayloadVersion {
+			return nil, fmt.Errorf("notifier %s: unsupported version %d", cfg.Name, cfg.Version)
+		}
+
+		client := NewClient(cfg.Url, cfg.Token)
+		client.Secret = cfg.Secret
+		if cfg.Version != 0 {
+			client.Version = cfg.Version
+		}
+		n = &named{Notifier: client, name: cfg.Name}
+	case "webhook":
+		n, err = newWebhook(cfg, tmpl)
+	case "discord":
+		n, err = newDiscord(cfg, tmpl)
+	case "slack":
+		n, err = newSlack(cfg, tmpl)
+	case "smtp":
+		n, err = newSmtp(cfg, tmpl)
+	case "ntfy":
+		n, err = newNtfy(cfg, tmpl)
+	case "gotify":
+		n, err = newGotify(cfg, tmpl)
+	case "stdout":
+		n, err = newWriter(cfg, tmpl, os.Stdout)
+	default:
+		return nil, fmt.Errorf("notifier %s: unknown type %q", cfg.Name, cfg.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Events) == 0 {
+		return n, nil
+	}
+
+	types := make(map[EventType]bool)
+	for _, t := range cfg.Events {
+		types[t] = true
+	}
+
+	return &filtered{Notifier: n, types: types}, nil
+}
+
+// NewMulti builds every configured notifier, their names must be unique as pending notifications are queued by
+// notifier name.
+func NewMulti(configs []Config) (Multi, error) {
+	var m Multi
+	names := make(map[string]bool)
+	for _, cfg := range configs {
+		n, err := New(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		if names[n.Name()] {
+			return nil, fmt.Errorf("notifier %s: duplicate name, set a distinct name on each notifier", n.Name())
+		}
+		names[n.Name()] = true
+
+		m = append(m, n)
+	}
+
+	return m, nil
+}
+
+// named overrides the name of a notifier with a fixed one.
+type named struct {
+	Notifier
+	name string
+}
+
+func (n *named) Name() string {
+	return n.name
+}
+
+// templates merges the title and template of the listed event types with the templates set per event type.
+func (cfg Config) templates() map[EventType]MessageTemplate {
+	sources := make(map[EventType]MessageTemplate)
+	for _, t := range cfg.Events {
+		sources[t] = MessageTemplate{Title: cfg.Title, Template: cfg.Template}
+	}
+
+	for t, source := range cfg.Templates {
+		merged := sources[t]
+		if source.Title != "" {
+			merged.Title = source.Title
+		}
+		if source.Template != "" {
+			merged.Template = source.Template
+		}
+		sources[t] = merged
+	}
+
+	return sources
+}
+
+func requireUrl(cfg Config) error {
+	if cfg.Url == "" {
+		return fmt.Errorf("notifier %s: missing url", cfg.Name)
+	}
+
+	return nil
+}
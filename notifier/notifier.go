@@ -0,0 +1,203 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// EventType identifies what changed on GAPS.
+type EventType string
+
+const (
+	GradeEvent   EventType = "grade"
+	AbsenceEvent EventType = "absence"
+	ModuleEvent  EventType = "module"
+	LessonEvent  EventType = "lesson"
+)
+
+// Event is a change detected by the scraper, only the field matching its type is set.
+type Event struct {
+	Type    EventType      `json:"type"`
+	Time    time.Time      `json:"time"`
+	Grade   *GradeChange   `json:"grade,omitempty"`
+	Absence *AbsenceChange `json:"absence,omitempty"`
+	Module  *ModuleChange  `json:"module,omitempty"`
+	Lesson  *LessonChange  `json:"lesson,omitempty"`
+}
+
+// GradeChange describes a new or updated grade. The previous values are empty for new grades.
+type GradeChange struct {
+	Course            string    `json:"course"`
+	Class             string    `json:"class"`
+	Type              string    `json:"type"`
+	Name              string    `json:"name"`
+	Date              time.Time `json:"date"`
+	Weight            float32   `json:"weight"`
+	Grade             string    `json:"grade"`
+	ClassMean         string    `json:"classMean"`
+	PreviousGrade     string    `json:"previousGrade,omitempty"`
+	PreviousClassMean string    `json:"previousClassMean,omitempty"`
+	New               bool      `json:"new"`
+}
+
+// AbsenceReason tells why an absence event was emitted.
+type AbsenceReason string
+
+const (
+	// AbsenceThreshold means one of the rates of a course went above a configured threshold.
+	AbsenceThreshold AbsenceReason = "threshold"
+	// AbsenceUnjustified means new unjustified periods appeared.
+	AbsenceUnjustified AbsenceReason = "unjustified"
+	// AbsenceJustified means previously unjustified periods were justified.
+	AbsenceJustified AbsenceReason = "justified"
+)
+
+// AbsenceChange describes a change in the absences of a course, rates are percentages.
+type AbsenceChange struct {
+	Reason              AbsenceReason `json:"reason"`
+	Course              string        `json:"course"`
+	Total               int           `json:"total"`
+	Justified           int           `json:"justified"`
+	Unjustified         int           `json:"unjustified"`
+	PreviousUnjustified int           `json:"previousUnjustified"`
+	RelativeRate        float64       `json:"relativeRate"`
+	AbsoluteRate        float64       `json:"absoluteRate"`
+	// Rate is the rate that crossed Threshold, either relative or absolute, for threshold events only.
+	Rate      string  `json:"rate,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+}
+
+// ModuleReason tells what changed on a report card module.
+type ModuleReason string
+
+const (
+	// ModuleGradePublished means the global grade of the module was published or changed.
+	ModuleGradePublished ModuleReason = "grade"
+	// ModuleSituationChanged means the module went e.g. from "En cours" to "Réussite" or "Échec".
+	ModuleSituationChanged ModuleReason = "situation"
+	// ModuleCreditsGranted means credits were granted for the module.
+	ModuleCreditsGranted ModuleReason = "credits"
+)
+
+// ModuleChange describes a change of a module on the report card.
+type ModuleChange struct {
+	Reason            ModuleReason `json:"reason"`
+	Module            string       `json:"module"`
+	Name              string       `json:"name"`
+	Year              uint         `json:"year"`
+	PassingGrade      string       `json:"passingGrade"`
+	Grade             string       `json:"grade"`
+	PreviousGrade     string       `json:"previousGrade,omitempty"`
+	Situation         string       `json:"situation"`
+	PreviousSituation string       `json:"previousSituation,omitempty"`
+	Credits           uint         `json:"credits"`
+	PreviousCredits   uint         `json:"previousCredits"`
+}
+
+// LessonReason tells what changed about a lesson of the schedule.
+type LessonReason string
+
+const (
+	LessonAdded       LessonReason = "added"
+	LessonRemoved     LessonReason = "removed"
+	LessonRescheduled LessonReason = "rescheduled"
+	LessonRelocated   LessonReason = "relocated"
+)
+
+// LessonChange describes a change of the student's schedule, the previous values are only set when relevant.
+type LessonChange struct {
+	Reason           LessonReason `json:"reason"`
+	Uid              string       `json:"uid"`
+	Summary          string       `json:"summary"`
+	Location         string       `json:"location"`
+	PreviousLocation string       `json:"previousLocation,omitempty"`
+	Start            time.Time    `json:"start"`
+	End              time.Time    `json:"end"`
+	PreviousStart    *time.Time   `json:"previousStart,omitempty"`
+	PreviousEnd      *time.Time   `json:"previousEnd,omitempty"`
+}
+
+// Notifier is a sink the scraper pushes events to.
+type Notifier interface {
+	// Name identifies the notifier in logs.
+	Name() string
+	Notify(ctx context.Context, event *Event) error
+}
+
+// Multi fans events out to several notifiers, a failing notifier doesn't prevent the others from being notified.
+type Multi []Notifier
+
+func (m Multi) Name() string {
+	return "multi"
+}
+
+func (m Multi) Notify(ctx context.Context, event *Event) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", n.Name(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Observer is called after each notification attempt, e.g. to collect metrics.
+type Observer func(name string, event *Event, err error)
+
+type observed struct {
+	Notifier
+	observer Observer
+}
+
+// Observe wraps a notifier so that observer is called with the outcome of each notification.
+func Observe(n Notifier, observer Observer) Notifier {
+	// events skipped by a filter must not be reported as notified
+	if f, ok := n.(*filtered); ok {
+		return &filtered{Notifier: Observe(f.Notifier, observer), types: f.types}
+	}
+
+	return &observed{Notifier: n, observer: observer}
+}
+
+func (o *observed) Notify(ctx context.Context, event *Event) error {
+	err := o.Notifier.Notify(ctx, event)
+	o.observer(o.Name(), event, err)
+	return err
+}
+
+// filtered only forwards the events of the given types.
+type filtered struct {
+	Notifier
+	types map[EventType]bool
+}
+
+func (f *filtered) Notify(ctx context.Context, event *Event) error {
+	if !f.types[event.Type] {
+		return nil
+	}
+
+	return f.Notifier.Notify(ctx, event)
+}
+
+var httpClient = &http.Client{
+	Timeout: time.Minute,
+}
+
+// post sends a request to a notification service, treating any non-2xx status as a failure.
+func post(req *http.Request) error {
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,333 @@
+package notifier_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"lutonite.dev/gaps-cli/notifier"
+)
+
+// request is what a test server received.
+type request struct {
+	path   string
+	header http.Header
+	body   string
+}
+
+// newServer records the requests it receives and answers them with status.
+func newServer(t *testing.T, status int) (*httptest.Server, func() []request) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, request{path: r.URL.Path, header: r.Header, body: string(body)})
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []request {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]request(nil), requests...)
+	}
+}
+
+func gradeEvent() *notifier.Event {
+	return &notifier.Event{
+		Type: notifier.GradeEvent,
+		Time: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		Grade: &notifier.GradeChange{
+			Course: "ARN", Class: "ARN-A", Type: "Cours", Name: "TE1", Grade: "5.0", ClassMean: "4.2", New: true,
+		},
+	}
+}
+
+func absenceEvent() *notifier.Event {
+	return &notifier.Event{
+		Type:    notifier.AbsenceEvent,
+		Time:    time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		Absence: &notifier.AbsenceChange{Course: "ARN", Reason: notifier.AbsenceUnjustified, Unjustified: 2},
+	}
+}
+
+func TestNotifiers(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   notifier.Config
+		check func(t *testing.T, req request)
+	}{
+		{
+			name: "webhook",
+			cfg:  notifier.Config{Type: "webhook", Token: "token", Secret: "secret", Headers: map[string]string{"X-Relay": "gaps"}},
+			check: func(t *testing.T, req request) {
+				var payload notifier.Payload
+				if err := json.Unmarshal([]byte(req.body), &payload); err != nil {
+					t.Fatal(err)
+				}
+				if payload.Version != notifier.PayloadVersion || payload.Grade == nil || payload.Title != "New grade in ARN" {
+					t.Errorf("payload = %s", req.body)
+				}
+				if req.header.Get("Authorization") != "Bearer token" || req.header.Get("X-Relay") != "gaps" {
+					t.Errorf("headers = %v", req.header)
+				}
+				if req.header.Get(notifier.IdempotencyKeyHeader) != payload.Id || req.header.Get(notifier.EventHeader) != "grade" {
+					t.Errorf("headers = %v, want the id and type of the event", req.header)
+				}
+				if err := notifier.Verify("secret", req.header.Get(notifier.SignatureHeader), []byte(req.body), time.Now(), time.Minute); err != nil {
+					t.Errorf("signature: %v", err)
+				}
+			},
+		},
+		{
+			name: "discord",
+			cfg:  notifier.Config{Type: "discord"},
+			check: func(t *testing.T, req request) {
+				if !strings.Contains(req.body, `"content":"**New grade in ARN**\nARN-A: TE1 (Cours), class average 4.2"`) {
+					t.Errorf("body = %s", req.body)
+				}
+			},
+		},
+		{
+			name: "slack",
+			cfg:  notifier.Config{Type: "slack"},
+			check: func(t *testing.T, req request) {
+				if !strings.Contains(req.body, `"text":"*New grade in ARN*\n`) {
+					t.Errorf("body = %s", req.body)
+				}
+			},
+		},
+		{
+			name: "ntfy",
+			cfg:  notifier.Config{Type: "ntfy", Token: "token", Priority: 4, Tags: []string{"school", "grades"}},
+			check: func(t *testing.T, req request) {
+				if req.body != "ARN-A: TE1 (Cours), class average 4.2" {
+					t.Errorf("body = %s", req.body)
+				}
+				if req.header.Get("Title") != "New grade in ARN" || req.header.Get("Priority") != "4" ||
+					req.header.Get("Tags") != "school,grades" || req.header.Get("Authorization") != "Bearer token" {
+					t.Errorf("headers = %v", req.header)
+				}
+			},
+		},
+		{
+			name: "gotify",
+			cfg:  notifier.Config{Type: "gotify", Token: "token", Priority: 5},
+			check: func(t *testing.T, req request) {
+				if req.path != "/message" || req.header.Get("X-Gotify-Key") != "token" {
+					t.Errorf("request = %s %v", req.path, req.header)
+				}
+				if !strings.Contains(req.body, `"priority":5`) || !strings.Contains(req.body, `"title":"New grade in ARN"`) {
+					t.Errorf("body = %s", req.body)
+				}
+			},
+		},
+		{
+			name: "api version 1",
+			cfg:  notifier.Config{Type: "api", Token: "key"},
+			check: func(t *testing.T, req request) {
+				if req.path != "/api/grade" || req.header.Get("Authorization") != "Bearer key" {
+					t.Errorf("request = %s %v", req.path, req.header)
+				}
+				if !strings.Contains(req.body, `"class_average":4.2`) {
+					t.Errorf("body = %s", req.body)
+				}
+			},
+		},
+		{
+			name: "api version 2",
+			cfg:  notifier.Config{Type: "api", Token: "key", Version: 2},
+			check: func(t *testing.T, req request) {
+				if req.path != "/api/events" || !strings.Contains(req.body, `"version":2`) {
+					t.Errorf("request = %s %s", req.path, req.body)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := newServer(t, http.StatusOK)
+			tt.cfg.Url = srv.URL
+			n, err := notifier.New(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := n.Notify(context.Background(), gradeEvent()); err != nil {
+				t.Fatalf("Notify() = %v", err)
+			}
+
+			reqs := requests()
+			if len(reqs) != 1 {
+				t.Fatalf("%d requests sent, want 1", len(reqs))
+			}
+			tt.check(t, reqs[0])
+		})
+	}
+}
+
+func TestNotifierFailure(t *testing.T) {
+	srv, _ := newServer(t, http.StatusBadGateway)
+	n, err := notifier.New(notifier.Config{Type: "webhook", Url: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := n.Notify(context.Background(), gradeEvent()); err == nil {
+		t.Error("Notify() succeeded on a failing server")
+	}
+}
+
+func TestNewMulti(t *testing.T) {
+	srv, requests := newServer(t, http.StatusOK)
+
+	tests := []struct {
+		name    string
+		configs []notifier.Config
+		wantErr string
+	}{
+		{"unknown type", []notifier.Config{{Type: "pager"}}, "unknown type"},
+		{"missing url", []notifier.Config{{Type: "ntfy"}}, "missing url"},
+		{"duplicate names", []notifier.Config{{Type: "ntfy", Url: srv.URL}, {Type: "ntfy", Url: srv.URL}}, "duplicate name"},
+		{"title without events", []notifier.Config{{Type: "ntfy", Url: srv.URL, Title: "{{.Grade.Name}}"}}, "events"},
+		{"template of an unknown event", []notifier.Config{{Type: "ntfy", Url: srv.URL, Templates: map[notifier.EventType]notifier.MessageTemplate{"exam": {Title: "exam"}}}}, "unknown event type"},
+		{"invalid template", []notifier.Config{{Type: "ntfy", Url: srv.URL, Events: []notifier.EventType{notifier.GradeEvent}, Template: "{{.Grade"}}, "invalid grade template"},
+		{"distinct names", []notifier.Config{{Type: "ntfy", Url: srv.URL}, {Type: "ntfy", Name: "backup", Url: srv.URL}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := notifier.NewMulti(tt.configs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("NewMulti() = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(m) != len(tt.configs) {
+				t.Errorf("%d notifiers built, want %d", len(m), len(tt.configs))
+			}
+		})
+	}
+
+	// the events not listed are not forwarded
+	m, err := notifier.NewMulti([]notifier.Config{
+		{Type: "ntfy", Name: "grades", Url: srv.URL + "/grades", Events: []notifier.EventType{notifier.GradeEvent}},
+		{Type: "ntfy", Name: "all", Url: srv.URL + "/all"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := len(requests())
+	if err := m.Notify(context.Background(), absenceEvent()); err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := requests()[before:]
+	if len(reqs) != 1 || reqs[0].path != "/all" {
+		t.Errorf("requests = %+v, want only the one of the unfiltered notifier", reqs)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		name      string
+		sources   map[notifier.EventType]notifier.MessageTemplate
+		event     *notifier.Event
+		wantTitle string
+		wantBody  string
+	}{
+		{
+			name:      "default grade",
+			event:     gradeEvent(),
+			wantTitle: "New grade in ARN",
+			wantBody:  "ARN-A: TE1 (Cours), class average 4.2",
+		},
+		{
+			name:      "default absence",
+			event:     absenceEvent(),
+			wantTitle: "New absence in ARN",
+			wantBody:  "2 unjustified period(s), relative rate 0.00%, absolute rate 0.00%",
+		},
+		{
+			name:      "custom grade",
+			sources:   map[notifier.EventType]notifier.MessageTemplate{notifier.GradeEvent: {Title: "{{.Grade.Name}}: {{.Grade.Grade}}"}},
+			event:     gradeEvent(),
+			wantTitle: "TE1: 5.0",
+			wantBody:  "ARN-A: TE1 (Cours), class average 4.2",
+		},
+		{
+			name:      "custom grade template on an absence",
+			sources:   map[notifier.EventType]notifier.MessageTemplate{notifier.GradeEvent: {Title: "{{.Grade.Name}}", Template: "{{.Grade.Grade}}"}},
+			event:     absenceEvent(),
+			wantTitle: "New absence in ARN",
+			wantBody:  "2 unjustified period(s), relative rate 0.00%, absolute rate 0.00%",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := notifier.NewTemplate(tt.sources)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg, err := tmpl.Render(tt.event)
+			if err != nil {
+				t.Fatalf("Render() = %v", err)
+			}
+			if msg.Title != tt.wantTitle || msg.Body != tt.wantBody {
+				t.Errorf("Render() = %q / %q, want %q / %q", msg.Title, msg.Body, tt.wantTitle, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestConfigTemplates(t *testing.T) {
+	srv, requests := newServer(t, http.StatusOK)
+
+	// the title applies to the listed events, templates override it per event type
+	n, err := notifier.New(notifier.Config{
+		Type:   "ntfy",
+		Url:    srv.URL,
+		Events: []notifier.EventType{notifier.GradeEvent, notifier.AbsenceEvent},
+		Title:  "GAPS update",
+		Templates: map[notifier.EventType]notifier.MessageTemplate{
+			notifier.AbsenceEvent: {Title: "Absent from {{.Absence.Course}}"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, event := range []*notifier.Event{gradeEvent(), absenceEvent()} {
+		if err := n.Notify(context.Background(), event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reqs := requests()
+	if len(reqs) != 2 {
+		t.Fatalf("%d requests sent, want 2", len(reqs))
+	}
+	if title := reqs[0].header.Get("Title"); title != "GAPS update" {
+		t.Errorf("grade title = %q, want GAPS update", title)
+	}
+	if title := reqs[1].header.Get("Title"); title != "Absent from ARN" {
+		t.Errorf("absence title = %q, want Absent from ARN", title)
+	}
+}
@@ -0,0 +1,140 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PayloadVersion is the version of the Payload schema sent by the notifiers. Version 1 is the legacy ApiGrade
+// of the notifications API, which only carries the class mean of new grades.
+const PayloadVersion = 2
+
+// Headers set on the requests of the notifiers sending a Payload.
+const (
+	SignatureHeader      = "X-Gaps-Signature"
+	EventHeader          = "X-Gaps-Event"
+	IdempotencyKeyHeader = "Idempotency-Key"
+)
+
+var (
+	ErrMissingSignature = errors.New("missing signature")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrExpiredSignature = errors.New("signature timestamp outside of the tolerance")
+)
+
+// Payload is the versioned body posted by the api and webhook notifiers. The event fields are inlined, so Time
+// is when the change was detected while Timestamp is when this delivery attempt was made.
+type Payload struct {
+	Version int `json:"version"`
+	// Id is the idempotency key of the event, identical across delivery attempts and notifiers.
+	Id        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	*Event
+	Title   string `json:"title,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// NewPayload wraps an event in the current payload schema.
+func NewPayload(event *Event, now time.Time) *Payload {
+	return &Payload{
+		Version:   PayloadVersion,
+		Id:        event.Key(),
+		Timestamp: now.UTC(),
+		Event:     event,
+	}
+}
+
+// Key derives the idempotency key of an event from its content. Queued events are stored as is, so the key
+// doesn't change when a delivery is retried.
+func (e *Event) Key() string {
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// Sign computes the value of the SignatureHeader of a body sent at the given time, in the form t=<unix>,v1=<hex>
+// where v1 is the HMAC-SHA256 of "<unix>.<body>" keyed by the shared secret.
+func Sign(secret string, at time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", timestamp, signature(secret, timestamp, body))
+}
+
+// Verify checks a SignatureHeader value against the body, rejecting signatures made more than tolerance away
+// from now to prevent replays. A zero tolerance disables the timestamp check.
+func Verify(secret string, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	if header == "" {
+		return ErrMissingSignature
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	at, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 {
+		if age := now.Sub(time.Unix(at, 0)); age > tolerance || age < -tolerance {
+			return ErrExpiredSignature
+		}
+	}
+
+	expected := []byte(signature(secret, timestamp, body))
+	for _, s := range signatures {
+		if hmac.Equal(expected, []byte(s)) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+func signature(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newPayloadRequest builds the request posting a payload, signed when a secret is configured.
+func newPayloadRequest(ctx context.Context, url string, payload *Payload, secret string) (*http.Request, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set(EventHeader, string(payload.Type))
+	req.Header.Set(IdempotencyKeyHeader, payload.Id)
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, payload.Timestamp, body))
+	}
+
+	return req, nil
+}
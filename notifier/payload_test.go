@@ -0,0 +1,60 @@
+package notifier_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"lutonite.dev/gaps-cli/notifier"
+)
+
+func TestVerify(t *testing.T) {
+	signedAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	body := []byte(`{"version":2,"id":"abc"}`)
+	header := notifier.Sign("secret", signedAt, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		header    string
+		body      []byte
+		now       time.Time
+		tolerance time.Duration
+		want      error
+	}{
+		{"valid signature", "secret", header, body, signedAt.Add(time.Minute), 5 * time.Minute, nil},
+		{"one of several signatures", "secret", header + ",v1=deadbeef", body, signedAt, 5 * time.Minute, nil},
+		{"tampered body", "secret", header, []byte(`{"version":2,"id":"abd"}`), signedAt, 5 * time.Minute, notifier.ErrInvalidSignature},
+		{"wrong secret", "other", header, body, signedAt, 5 * time.Minute, notifier.ErrInvalidSignature},
+		{"timestamp too old", "secret", header, body, signedAt.Add(6 * time.Minute), 5 * time.Minute, notifier.ErrExpiredSignature},
+		{"timestamp in the future", "secret", header, body, signedAt.Add(-6 * time.Minute), 5 * time.Minute, notifier.ErrExpiredSignature},
+		{"timestamp check disabled", "secret", header, body, signedAt.Add(24 * time.Hour), 0, nil},
+		{"missing header", "secret", "", body, signedAt, 5 * time.Minute, notifier.ErrMissingSignature},
+		{"missing timestamp", "secret", "v1=deadbeef", body, signedAt, 5 * time.Minute, notifier.ErrInvalidSignature},
+		{"invalid timestamp", "secret", "t=yesterday,v1=deadbeef", body, signedAt, 5 * time.Minute, notifier.ErrInvalidSignature},
+		{"missing signature", "secret", fmt.Sprintf("t=%d", signedAt.Unix()), body, signedAt, 5 * time.Minute, notifier.ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := notifier.Verify(tt.secret, tt.header, tt.body, tt.now, tt.tolerance)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventKey(t *testing.T) {
+	event := &notifier.Event{Type: notifier.GradeEvent, Grade: &notifier.GradeChange{Course: "ARN", Name: "TE1", Grade: "5.0"}}
+	same := &notifier.Event{Type: notifier.GradeEvent, Grade: &notifier.GradeChange{Course: "ARN", Name: "TE1", Grade: "5.0"}}
+	updated := &notifier.Event{Type: notifier.GradeEvent, Grade: &notifier.GradeChange{Course: "ARN", Name: "TE1", Grade: "5.5"}}
+
+	if event.Key() != same.Key() {
+		t.Errorf("identical events have different keys %s and %s", event.Key(), same.Key())
+	}
+	if event.Key() == updated.Key() {
+		t.Errorf("different events share the key %s", event.Key())
+	}
+}
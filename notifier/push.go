@@ -0,0 +1,95 @@
+package notifier
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ntfy publishes to a topic URL of an ntfy server, e.g. https://ntfy.sh/my-grades.
+type ntfy struct {
+	name     string
+	url      string
+	token    string
+	priority int
+	tags     []string
+	tmpl     *Template
+}
+
+func newNtfy(cfg Config, tmpl *Template) (Notifier, error) {
+	if err := requireUrl(cfg); err != nil {
+		return nil, err
+	}
+
+	return &ntfy{name: cfg.Name, url: cfg.Url, token: cfg.Token, priority: cfg.Priority, tags: cfg.Tags, tmpl: tmpl}, nil
+}
+
+func (n *ntfy) Name() string {
+	return n.name
+}
+
+func (n *ntfy) Notify(ctx context.Context, event *Event) error {
+	msg, err := n.tmpl.Render(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", n.url, strings.NewReader(msg.Body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Title", msg.Title)
+	if n.priority != 0 {
+		req.Header.Set("Priority", strconv.Itoa(n.priority))
+	}
+	if len(n.tags) > 0 {
+		req.Header.Set("Tags", strings.Join(n.tags, ","))
+	}
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	return post(req)
+}
+
+// gotify sends messages to a Gotify server with an application token.
+type gotify struct {
+	name     string
+	url      string
+	token    string
+	priority int
+	tmpl     *Template
+}
+
+func newGotify(cfg Config, tmpl *Template) (Notifier, error) {
+	if err := requireUrl(cfg); err != nil {
+		return nil, err
+	}
+
+	return &gotify{name: cfg.Name, url: strings.TrimSuffix(cfg.Url, "/"), token: cfg.Token, priority: cfg.Priority, tmpl: tmpl}, nil
+}
+
+func (g *gotify) Name() string {
+	return g.name
+}
+
+func (g *gotify) Notify(ctx context.Context, event *Event) error {
+	msg, err := g.tmpl.Render(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := newJsonRequest(ctx, g.url+"/message", map[string]any{
+		"title":    msg.Title,
+		"message":  msg.Body,
+		"priority": g.priority,
+	})
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Gotify-Key", g.token)
+	return post(req)
+}
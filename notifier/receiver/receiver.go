@@ -0,0 +1,156 @@
+// Package receiver is a reference implementation of a server receiving the payloads of the api and webhook
+// notifiers. It verifies their signature and drops the events it already handled, so relays can trust and
+// deduplicate the events pushed by the scraper.
+package receiver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"lutonite.dev/gaps-cli/notifier"
+)
+
+// DefaultTolerance is the maximum difference between the signature timestamp and the receiver clock.
+const DefaultTolerance = 5 * time.Minute
+
+// maxBodySize bounds the payloads read by the receiver.
+const maxBodySize = 1 << 20
+
+// HandlerFunc handles a verified event, returning an error makes the sender retry it later.
+type HandlerFunc func(ctx context.Context, payload *notifier.Payload) error
+
+// Receiver is an http.Handler accepting signed notifier payloads.
+type Receiver struct {
+	secret    string
+	handle    HandlerFunc
+	tolerance time.Duration
+	seen      *Dedup
+	now       func() time.Time
+}
+
+// New returns a receiver verifying the payloads against secret before passing them to handle. Signatures are
+// not checked when secret is empty, which should only be done behind another authentication.
+func New(secret string, handle HandlerFunc) *Receiver {
+	return &Receiver{
+		secret:    secret,
+		handle:    handle,
+		tolerance: DefaultTolerance,
+		seen:      NewDedup(24 * time.Hour),
+		now:       time.Now,
+	}
+}
+
+// SetTolerance changes the accepted clock skew between the sender and the receiver, zero accepts any timestamp.
+func (r *Receiver) SetTolerance(tolerance time.Duration) {
+	r.tolerance = tolerance
+}
+
+// SetDedup replaces the store of handled event ids, e.g. to share it between several receivers.
+func (r *Receiver) SetDedup(d *Dedup) {
+	r.seen = d
+}
+
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "could not read body", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if r.secret != "" {
+		err := notifier.Verify(r.secret, req.Header.Get(notifier.SignatureHeader), body, r.now(), r.tolerance)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	payload, err := Decode(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// duplicates are acknowledged so the sender stops retrying them
+	if !r.seen.Reserve(payload.Id) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := r.handle(req.Context(), payload); err != nil {
+		r.seen.Release(payload.Id)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Decode parses a payload, rejecting the versions this package doesn't know.
+func Decode(body []byte) (*notifier.Payload, error) {
+	var payload notifier.Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case payload.Version != notifier.PayloadVersion:
+		return nil, errors.New("unsupported payload version")
+	case payload.Id == "" || payload.Event == nil || payload.Type == "":
+		return nil, errors.New("incomplete payload")
+	}
+
+	return &payload, nil
+}
+
+// Dedup remembers the ids of the handled events for a while.
+type Dedup struct {
+	ttl time.Duration
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// NewDedup returns an empty store forgetting ids after ttl, which should exceed the time the sender keeps
+// retrying an event.
+func NewDedup(ttl time.Duration) *Dedup {
+	return &Dedup{ttl: ttl, seen: make(map[string]time.Time)}
+}
+
+// Reserve marks an id as handled, returning false when it already was.
+func (d *Dedup) Reserve(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for key, at := range d.seen {
+		if now.Sub(at) > d.ttl {
+			delete(d.seen, key)
+		}
+	}
+
+	if _, ok := d.seen[id]; ok {
+		return false
+	}
+
+	d.seen[id] = now
+	return true
+}
+
+// Release forgets an id whose handling failed, so that it is accepted again on retry.
+func (d *Dedup) Release(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.seen, id)
+}
@@ -0,0 +1,145 @@
+package receiver_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"lutonite.dev/gaps-cli/notifier"
+	"lutonite.dev/gaps-cli/notifier/receiver"
+)
+
+func newRequest(t *testing.T, payload *notifier.Payload, secret string) *http.Request {
+	t.Helper()
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	if secret != "" {
+		req.Header.Set(notifier.SignatureHeader, notifier.Sign(secret, payload.Timestamp, body))
+	}
+
+	return req
+}
+
+func newPayload(grade string, at time.Time) *notifier.Payload {
+	return notifier.NewPayload(&notifier.Event{
+		Type:  notifier.GradeEvent,
+		Time:  at,
+		Grade: &notifier.GradeChange{Course: "ARN", Name: "TE1", Grade: grade},
+	}, at)
+}
+
+func TestReceiver(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		payload *notifier.Payload
+		secret  string
+		method  string
+		want    int
+		handled bool
+	}{
+		{"valid signature", newPayload("5.0", now), "secret", http.MethodPost, http.StatusNoContent, true},
+		{"wrong secret", newPayload("5.0", now), "other", http.MethodPost, http.StatusUnauthorized, false},
+		{"missing signature", newPayload("5.0", now), "", http.MethodPost, http.StatusUnauthorized, false},
+		{"expired signature", newPayload("5.0", now.Add(-time.Hour)), "secret", http.MethodPost, http.StatusUnauthorized, false},
+		{"unsupported version", &notifier.Payload{Version: 1, Id: "abc", Timestamp: now, Event: &notifier.Event{Type: notifier.GradeEvent}}, "secret", http.MethodPost, http.StatusBadRequest, false},
+		{"incomplete payload", &notifier.Payload{Version: notifier.PayloadVersion, Timestamp: now}, "secret", http.MethodPost, http.StatusBadRequest, false},
+		{"wrong method", newPayload("5.0", now), "secret", http.MethodGet, http.StatusMethodNotAllowed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handled := false
+			r := receiver.New("secret", func(_ context.Context, _ *notifier.Payload) error {
+				handled = true
+				return nil
+			})
+
+			req := newRequest(t, tt.payload, tt.secret)
+			req.Method = tt.method
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			if handled != tt.handled {
+				t.Errorf("handled = %t, want %t", handled, tt.handled)
+			}
+		})
+	}
+}
+
+func TestReceiverDuplicates(t *testing.T) {
+	var handled []string
+	fail := true
+	r := receiver.New("secret", func(_ context.Context, payload *notifier.Payload) error {
+		if fail {
+			return errors.New("relay unavailable")
+		}
+
+		handled = append(handled, payload.Id)
+		return nil
+	})
+
+	payload := newPayload("5.0", time.Now())
+	deliver := func() int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newRequest(t, payload, "secret"))
+		return w.Code
+	}
+
+	// a failing handler releases the id, so the retry is handled
+	if code := deliver(); code != http.StatusInternalServerError {
+		t.Fatalf("failing handler status = %d, want %d", code, http.StatusInternalServerError)
+	}
+
+	fail = false
+	if code := deliver(); code != http.StatusNoContent {
+		t.Fatalf("retry status = %d, want %d", code, http.StatusNoContent)
+	}
+
+	// duplicates are acknowledged without being handled again
+	if code := deliver(); code != http.StatusNoContent {
+		t.Fatalf("duplicate status = %d, want %d", code, http.StatusNoContent)
+	}
+	if len(handled) != 1 || handled[0] != payload.Id {
+		t.Errorf("handled = %v, want only %s", handled, payload.Id)
+	}
+}
+
+func TestDedup(t *testing.T) {
+	d := receiver.NewDedup(time.Hour)
+	if !d.Reserve("a") {
+		t.Fatal("first reservation of a refused")
+	}
+	if d.Reserve("a") {
+		t.Error("duplicate reservation of a accepted")
+	}
+	if !d.Reserve("b") {
+		t.Error("reservation of b refused")
+	}
+
+	d.Release("a")
+	if !d.Reserve("a") {
+		t.Error("reservation of the released a refused")
+	}
+
+	expiring := receiver.NewDedup(time.Millisecond)
+	expiring.Reserve("a")
+	time.Sleep(5 * time.Millisecond)
+	if !expiring.Reserve("a") {
+		t.Error("reservation of the expired a refused")
+	}
+}
@@ -6,8 +6,39 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
+
+	"lutonite.dev/gaps-cli/parser"
 )
 
+func (c *Client) Name() string {
+	return "api"
+}
+
+// Notify relays events to the notifications API. The version 1 of the API only supports grades with a numeric
+// class mean, other events are ignored.
+func (c *Client) Notify(ctx context.Context, event *Event) error {
+	if c.Version >= PayloadVersion {
+		return c.SendEvent(ctx, event)
+	}
+
+	if event.Type != GradeEvent || event.Grade == nil {
+		return nil
+	}
+
+	mean, ok := parser.ParseGradeValue(event.Grade.ClassMean).Float()
+	if !ok {
+		return nil
+	}
+
+	return c.SendGrade(ctx, &ApiGrade{
+		Course: event.Grade.Course,
+		Class:  event.Grade.Class,
+		Name:   event.Grade.Name,
+		Mean:   float32(mean),
+	})
+}
+
 func (c *Client) SendGrade(ctx context.Context, grade *ApiGrade) error {
 	body, err := json.Marshal(grade)
 	if err != nil {
@@ -22,6 +53,20 @@ func (c *Client) SendGrade(ctx context.Context, grade *ApiGrade) error {
 		return err
 	}
 
+	if c.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(c.Secret, time.Now(), body))
+	}
+
 	req = req.WithContext(ctx)
 	return c.sendRequest(req, nil)
 }
+
+// SendEvent posts an event with the version 2 of the API contract.
+func (c *Client) SendEvent(ctx context.Context, event *Event) error {
+	req, err := newPayloadRequest(ctx, fmt.Sprintf("%s/api/events", c.BaseUrl), NewPayload(event, time.Now()), c.Secret)
+	if err != nil {
+		return err
+	}
+
+	return c.sendRequest(req, nil)
+}
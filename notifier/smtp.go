@@ -0,0 +1,71 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// email sends messages through an SMTP server, STARTTLS is used whenever the server supports it.
+type email struct {
+	name string
+	cfg  SmtpConfig
+	tmpl *Template
+}
+
+func newSmtp(cfg Config, tmpl *Template) (Notifier, error) {
+	if cfg.Smtp.Host == "" || cfg.Smtp.From == "" || len(cfg.Smtp.To) == 0 {
+		return nil, fmt.Errorf("notifier %s: smtp host, from and to are required", cfg.Name)
+	}
+	if cfg.Smtp.Port == 0 {
+		cfg.Smtp.Port = 587
+	}
+
+	return &email{name: cfg.Name, cfg: cfg.Smtp, tmpl: tmpl}, nil
+}
+
+func (e *email) Name() string {
+	return e.name
+}
+
+func (e *email) Notify(ctx context.Context, event *Event) error {
+	msg, err := e.tmpl.Render(event)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(e.cfg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Title))
+	fmt.Fprintf(&buf, "Date: %s\r\n", event.Time.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+
+	var auth smtp.Auth
+	if e.cfg.Username != "" {
+		auth = smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)
+	}
+
+	// net/smtp has no context support, run the exchange in the background so cancellation isn't blocked on it
+	addr := net.JoinHostPort(e.cfg.Host, strconv.Itoa(e.cfg.Port))
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, e.cfg.From, e.cfg.To, buf.Bytes())
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		return err
+	}
+}
@@ -0,0 +1,110 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// DefaultTemplates are the title and body templates used when a notifier doesn't define its own. The student's
+// own grade is left out on purpose since notifications are often sent to shared channels, it remains available
+// to custom templates as .Grade.Grade.
+var DefaultTemplates = map[EventType][2]string{
+	GradeEvent: {
+		`{{if .Grade.New}}New grade{{else}}Updated grade{{end}} in {{.Grade.Course}}`,
+		`{{.Grade.Class}}: {{.Grade.Name}} ({{.Grade.Type}}), class average {{.Grade.ClassMean}}`,
+	},
+	AbsenceEvent: {
+		`{{with .Absence}}{{if eq .Reason "threshold"}}Absence threshold reached{{else if eq .Reason "justified"}}Absence justified{{else}}New absence{{end}} in {{.Course}}{{end}}`,
+		`{{with .Absence}}{{if eq .Reason "threshold"}}{{.Rate}} rate above {{printf "%.0f" .Threshold}}%, {{end}}` +
+			`{{.Unjustified}} unjustified period(s), relative rate {{printf "%.2f" .RelativeRate}}%, absolute rate {{printf "%.2f" .AbsoluteRate}}%{{end}}`,
+	},
+	LessonEvent: {
+		`{{with .Lesson}}Lesson {{.Reason}}: {{.Summary}}{{end}}`,
+		`{{with .Lesson}}{{if eq .Reason "rescheduled"}}{{.PreviousStart.Local.Format "Mon 02.01 15:04"}} -> {{end}}` +
+			`{{.Start.Local.Format "Mon 02.01 15:04"}}-{{.End.Local.Format "15:04"}}` +
+			`{{if eq .Reason "relocated"}}, {{.PreviousLocation}} -> {{.Location}}{{else if .Location}}, {{.Location}}{{end}}{{end}}`,
+	},
+	ModuleEvent: {
+		`{{with .Module}}{{if eq .Reason "grade"}}Module grade published{{else if eq .Reason "credits"}}Credits granted{{else}}Module situation changed{{end}} for {{.Module}}{{end}}`,
+		`{{with .Module}}{{.Name}}: {{if eq .Reason "credits"}}{{.Credits}} credit(s){{else if eq .Reason "situation"}}{{with .PreviousSituation}}{{.}} -> {{end}}{{.Situation}}{{else}}the grade is available on GAPS{{end}}{{end}}`,
+	},
+}
+
+// Message is a rendered event.
+type Message struct {
+	Title string
+	Body  string
+}
+
+// MessageTemplate holds the title and body template sources of an event type.
+type MessageTemplate struct {
+	Title    string `mapstructure:"title"`
+	Template string `mapstructure:"template"`
+}
+
+// Template renders events to messages, falling back to DefaultTemplates for the parts it doesn't override. The
+// templates are kept per event type as each type only sets its own field of the Event.
+type Template struct {
+	titles map[EventType]*template.Template
+	bodies map[EventType]*template.Template
+}
+
+func NewTemplate(sources map[EventType]MessageTemplate) (*Template, error) {
+	t := &Template{
+		titles: make(map[EventType]*template.Template),
+		bodies: make(map[EventType]*template.Template),
+	}
+
+	for eventType, source := range sources {
+		if _, ok := DefaultTemplates[eventType]; !ok {
+			return nil, fmt.Errorf("unknown event type %q", eventType)
+		}
+
+		var err error
+		if source.Title != "" {
+			if t.titles[eventType], err = template.New("title").Parse(source.Title); err != nil {
+				return nil, fmt.Errorf("invalid %s title template: %w", eventType, err)
+			}
+		}
+		if source.Template != "" {
+			if t.bodies[eventType], err = template.New("body").Parse(source.Template); err != nil {
+				return nil, fmt.Errorf("invalid %s template: %w", eventType, err)
+			}
+		}
+	}
+
+	return t, nil
+}
+
+func (t *Template) Render(event *Event) (Message, error) {
+	defaults := DefaultTemplates[event.Type]
+
+	title, err := execute(t.titles[event.Type], defaults[0], event)
+	if err != nil {
+		return Message{}, err
+	}
+
+	body, err := execute(t.bodies[event.Type], defaults[1], event)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return Message{Title: title, Body: body}, nil
+}
+
+func execute(tmpl *template.Template, fallback string, event *Event) (string, error) {
+	if tmpl == nil {
+		var err error
+		if tmpl, err = template.New("default").Parse(fallback); err != nil {
+			return "", err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, event); err != nil {
+		return "", fmt.Errorf("couldn't render %s event: %w", event.Type, err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,71 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// webhook posts the event as JSON along with its rendered message to any URL.
+type webhook struct {
+	name    string
+	url     string
+	token   string
+	secret  string
+	headers map[string]string
+	tmpl    *Template
+}
+
+func newWebhook(cfg Config, tmpl *Template) (Notifier, error) {
+	if err := requireUrl(cfg); err != nil {
+		return nil, err
+	}
+
+	return &webhook{name: cfg.Name, url: cfg.Url, token: cfg.Token, secret: cfg.Secret, headers: cfg.Headers, tmpl: tmpl}, nil
+}
+
+func (w *webhook) Name() string {
+	return w.name
+}
+
+func (w *webhook) Notify(ctx context.Context, event *Event) error {
+	msg, err := w.tmpl.Render(event)
+	if err != nil {
+		return err
+	}
+
+	payload := NewPayload(event, time.Now())
+	payload.Title = msg.Title
+	payload.Message = msg.Body
+
+	req, err := newPayloadRequest(ctx, w.url, payload, w.secret)
+	if err != nil {
+		return err
+	}
+
+	if w.token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.token)
+	}
+	for key, value := range w.headers {
+		req.Header.Set(key, value)
+	}
+
+	return post(req)
+}
+
+func newJsonRequest(ctx context.Context, url string, payload any) (*http.Request, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	return req, nil
+}
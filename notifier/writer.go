@@ -0,0 +1,49 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// writer prints events, either as rendered text or as one JSON object per line.
+type writer struct {
+	name string
+	json bool
+	tmpl *Template
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func newWriter(cfg Config, tmpl *Template, out io.Writer) (Notifier, error) {
+	if cfg.Format != "" && cfg.Format != "text" && cfg.Format != "json" {
+		return nil, fmt.Errorf("notifier %s: unknown format %q", cfg.Name, cfg.Format)
+	}
+
+	return &writer{name: cfg.Name, json: cfg.Format == "json", tmpl: tmpl, out: out}, nil
+}
+
+func (w *writer) Name() string {
+	return w.name
+}
+
+func (w *writer) Notify(_ context.Context, event *Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.json {
+		return json.NewEncoder(w.out).Encode(event)
+	}
+
+	msg, err := w.tmpl.Render(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w.out, "[%s] %s: %s\n", event.Time.Format(time.DateTime), msg.Title, msg.Body)
+	return err
+}
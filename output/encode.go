@@ -0,0 +1,108 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// writeNdjson writes each element of a slice on its own line, other values on a single line.
+func writeNdjson(w io.Writer, data any) error {
+	enc := json.NewEncoder(w)
+
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return enc.Encode(data)
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		if err := enc.Encode(v.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeYaml goes through JSON so that the keys and their order match the JSON output.
+func writeYaml(w io.Writer, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(raw, &node); err != nil {
+		return err
+	}
+	clearStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// clearStyle turns the flow style of the JSON document into the usual block style.
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// writeTemplate executes a template on the JSON form of data, so that the fields are named as in the JSON output.
+func writeTemplate(w io.Writer, source string, data any) error {
+	if strings.HasPrefix(source, "@") {
+		content, err := os.ReadFile(source[1:])
+		if err != nil {
+			return err
+		}
+		source = string(content)
+	}
+
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(source)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var generic any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return err
+	}
+
+	return tmpl.Execute(w, generic)
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		raw, err := json.Marshal(v)
+		return string(raw), err
+	},
+	"join": func(sep string, values []any) string {
+		parts := make([]string, len(values))
+		for i, v := range values {
+			parts[i] = fmt.Sprint(v)
+		}
+		return strings.Join(parts, sep)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
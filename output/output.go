@@ -0,0 +1,181 @@
+// Package output renders the results of the commands in the format chosen by the user.
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+type Format string
+
+const (
+	TableFormat    Format = "table"
+	JsonFormat     Format = "json"
+	NdjsonFormat   Format = "ndjson"
+	YamlFormat     Format = "yaml"
+	CsvFormat      Format = "csv"
+	XlsxFormat     Format = "xlsx"
+	MarkdownFormat Format = "markdown"
+	HtmlFormat     Format = "html"
+	TemplateFormat Format = "template"
+	// TextFormat is the plain output of the commands printing a single value
+	TextFormat Format = "text"
+)
+
+var formats = []Format{TableFormat, JsonFormat, NdjsonFormat, YamlFormat, CsvFormat, XlsxFormat, MarkdownFormat, HtmlFormat, TemplateFormat}
+
+var (
+	ErrMissingTemplate = errors.New("the template format needs a --template")
+	ErrBinaryTerminal  = errors.New("the xlsx format can't be written to a terminal, use --output")
+)
+
+func (f *Format) String() string {
+	return string(*f)
+}
+
+func (f *Format) Set(value string) error {
+	for _, known := range append(formats, TextFormat) {
+		if Format(strings.ToLower(value)) == known {
+			*f = known
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown output format %q", value)
+}
+
+func (f *Format) Type() string {
+	return "string"
+}
+
+// View is the result of a command. Data is written by the structured formats and given to templates, Table
+// fills the table of the tabular formats and Text writes the text format.
+type View struct {
+	Data  any
+	Table func(t *Table)
+	// Records replaces Table for the csv and xlsx formats, with a row per record and raw values for spreadsheets
+	Records func(t *Table)
+	Text    func(w io.Writer) error
+}
+
+// Printer writes views according to the --format, --template and --output flags.
+type Printer struct {
+	Format   Format
+	Template string
+	Output   string
+
+	flags *pflag.FlagSet
+}
+
+// AddFlags registers the output flags of a command, def being its default format.
+func (p *Printer) AddFlags(flags *pflag.FlagSet, def Format) {
+	names := make([]string, 0, len(formats)+1)
+	if def == TextFormat {
+		names = append(names, string(TextFormat))
+	}
+	for _, f := range formats {
+		names = append(names, string(f))
+	}
+
+	p.flags = flags
+	p.Format = def
+	flags.VarP(&p.Format, "format", "o", fmt.Sprintf("Output format (%s)", strings.Join(names, ", ")))
+	flags.StringVar(&p.Template, "template", "", "Go text/template to render the output with, or @file to read it from a file, implies --format template")
+	flags.StringVar(&p.Output, "output", "", "Write the output to this file instead of stdout")
+}
+
+// format is the format the views are printed in, a template implying the template format.
+func (p *Printer) format() Format {
+	if p.Template != "" && (p.flags == nil || !p.flags.Changed("format")) {
+		return TemplateFormat
+	}
+
+	return p.Format
+}
+
+// PrintsRecords tells whether the views are printed from their records rather than their table.
+func (p *Printer) PrintsRecords() bool {
+	return p.format() == CsvFormat || p.format() == XlsxFormat
+}
+
+// Print writes the view to the output file, or stdout.
+func (p *Printer) Print(v View) (err error) {
+	format := p.format()
+
+	var w io.Writer = os.Stdout
+	if p.Output != "" && p.Output != "-" {
+		f, err := os.Create(p.Output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = f
+	}
+
+	// colors would end up as escape sequences in files and in the other formats
+	if format != TableFormat || !isTerminal(w) {
+		DisableColors()
+	}
+
+	switch format {
+	case TableFormat, CsvFormat, XlsxFormat, MarkdownFormat, HtmlFormat:
+		fill := v.Table
+		if p.PrintsRecords() && v.Records != nil {
+			fill = v.Records
+		}
+		if fill == nil {
+			return unsupported(format)
+		}
+		if format == XlsxFormat && isTerminal(w) {
+			return ErrBinaryTerminal
+		}
+
+		t := NewTable()
+		fill(t)
+		return t.write(w, format)
+	case TextFormat:
+		if v.Text == nil {
+			return unsupported(format)
+		}
+		return v.Text(w)
+	case JsonFormat:
+		return json.NewEncoder(w).Encode(v.Data)
+	case NdjsonFormat:
+		return writeNdjson(w, v.Data)
+	case YamlFormat:
+		return writeYaml(w, v.Data)
+	case TemplateFormat:
+		if p.Template == "" {
+			return ErrMissingTemplate
+		}
+		return writeTemplate(w, p.Template, v.Data)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// DisableColors stops the tables from being colored, e.g. for --no-color or NO_COLOR.
+func DisableColors() {
+	text.DisableColors()
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+func unsupported(format Format) error {
+	return fmt.Errorf("this command doesn't support the %s format", format)
+}
@@ -0,0 +1,257 @@
+package output
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/pflag"
+)
+
+type record struct {
+	Name  string  `json:"name"`
+	Grade float64 `json:"grade"`
+}
+
+var records = []record{{"TE1", 5.5}, {"Labo \"2\", final", 4}}
+
+var view = View{
+	Data: records,
+	Table: func(t *Table) {
+		t.AppendHeader(table.Row{"Name", "Grade"})
+		for _, r := range records {
+			t.AppendRow(table.Row{r.Name, r.Grade})
+		}
+	},
+	Records: func(t *Table) {
+		t.Name = "Grades: 2024/2025"
+		t.AppendHeader(table.Row{"name", "grade", "pending"})
+		for _, r := range records {
+			t.AppendRow(table.Row{r.Name, r.Grade, nil})
+		}
+		t.AppendRow(table.Row{"TE3", math.NaN(), true})
+	},
+}
+
+// printView runs a printer configured by the command line args, returning what it wrote.
+func printView(t *testing.T, args ...string) string {
+	t.Helper()
+
+	var p Printer
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	p.AddFlags(flags, TableFormat)
+	if err := flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	p.Output = t.TempDir() + "/output"
+	if err := p.Print(view); err != nil {
+		t.Fatalf("Print() = %v", err)
+	}
+
+	data, err := os.ReadFile(p.Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func TestPrint(t *testing.T) {
+	template := t.TempDir() + "/template.txt"
+	if err := os.WriteFile(template, []byte(`{{range .}}{{.name}}={{.grade}};{{end}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "csv records are escaped",
+			args: []string{"--format", "csv"},
+			want: "name,grade,pending\nTE1,5.5,\n\"Labo \"\"2\"\", final\",4,\nTE3,NaN,true\n",
+		},
+		{
+			name: "ndjson writes an element per line",
+			args: []string{"--format", "ndjson"},
+			want: "{\"name\":\"TE1\",\"grade\":5.5}\n{\"name\":\"Labo \\\"2\\\", final\",\"grade\":4}\n",
+		},
+		{
+			name: "json",
+			args: []string{"--format", "json"},
+			want: "[{\"name\":\"TE1\",\"grade\":5.5},{\"name\":\"Labo \\\"2\\\", final\",\"grade\":4}]\n",
+		},
+		{
+			name: "yaml keeps the json keys",
+			args: []string{"--format", "yaml"},
+			want: "- name: TE1\n  grade: 5.5\n- name: Labo \"2\", final\n  grade: 4\n",
+		},
+		{
+			name: "template",
+			args: []string{"--template", "{{len .}} grades"},
+			want: "2 grades",
+		},
+		{
+			name: "template from a file",
+			args: []string{"--template", "@" + template},
+			want: "TE1=5.5;Labo \"2\", final=4;",
+		},
+		{
+			name: "an explicit format wins over the template",
+			args: []string{"--template", "{{len .}} grades", "--format", "ndjson"},
+			want: "{\"name\":\"TE1\",\"grade\":5.5}\n{\"name\":\"Labo \\\"2\\\", final\",\"grade\":4}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := printView(t, tt.args...); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNdjsonSingleValue(t *testing.T) {
+	var b strings.Builder
+	if err := writeNdjson(&b, record{"TE1", 5}); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != "{\"name\":\"TE1\",\"grade\":5}\n" {
+		t.Errorf("output = %q", got)
+	}
+}
+
+func TestPrintsRecords(t *testing.T) {
+	for _, tt := range []struct {
+		format   Format
+		template string
+		want     bool
+	}{
+		{CsvFormat, "", true},
+		{XlsxFormat, "", true},
+		{TableFormat, "", false},
+		{JsonFormat, "", false},
+		{CsvFormat, "{{.}}", false},
+	} {
+		p := Printer{Format: tt.format, Template: tt.template}
+		if got := p.PrintsRecords(); got != tt.want {
+			t.Errorf("PrintsRecords() of %s with template %q = %t, want %t", tt.format, tt.template, got, tt.want)
+		}
+	}
+}
+
+type sheet struct {
+	Rows []struct {
+		R     string `xml:"r,attr"`
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Style  string `xml:"s,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func TestWriteXlsx(t *testing.T) {
+	data := printView(t, "--format", "xlsx")
+
+	z, err := zip.NewReader(strings.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("not a zip: %v", err)
+	}
+
+	parts := make(map[string]string)
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = string(content)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		content, ok := parts[name]
+		if !ok {
+			t.Fatalf("part %s missing", name)
+		}
+
+		// every part must be well-formed
+		dec := xml.NewDecoder(strings.NewReader(content))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("part %s is invalid: %v", name, err)
+			}
+		}
+	}
+
+	if !strings.Contains(parts["xl/workbook.xml"], `name="Grades 20242025"`) {
+		t.Errorf("workbook = %s, want the sanitized sheet name", parts["xl/workbook.xml"])
+	}
+
+	var s sheet
+	if err := xml.Unmarshal([]byte(parts["xl/worksheets/sheet1.xml"]), &s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Rows) != 4 {
+		t.Fatalf("%d rows, want the header and 3 records", len(s.Rows))
+	}
+
+	header := s.Rows[0].Cells
+	if len(header) != 3 || header[0].Inline != "name" || header[0].Style != "1" {
+		t.Errorf("header = %+v, want bold inline strings", header)
+	}
+
+	cells := s.Rows[2].Cells
+	if len(cells) != 2 {
+		t.Fatalf("second record has %d cells, want the empty one left out", len(cells))
+	}
+	if cells[0].Ref != "A3" || cells[0].Type != "inlineStr" || cells[0].Inline != `Labo "2", final` {
+		t.Errorf("text cell = %+v", cells[0])
+	}
+	if cells[1].Ref != "B3" || cells[1].Type != "" || cells[1].Value != "4" {
+		t.Errorf("grade cell = %+v, want a numeric cell", cells[1])
+	}
+
+	last := s.Rows[3].Cells
+	if len(last) != 2 || last[1].Ref != "C4" || last[1].Type != "b" || last[1].Value != "1" {
+		t.Errorf("last record = %+v, want NaN left out and a boolean cell", last)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestSheetName(t *testing.T) {
+	for name, want := range map[string]string{
+		"Grades":                                "Grades",
+		"Report card [2024/2025]":               "Report card 20242025",
+		"":                                      "Sheet1",
+		"?*:":                                   "Sheet1",
+		"A sheet name that is way too long for": "A sheet name that is way too lo",
+	} {
+		if got := sheetName(name); got != want {
+			t.Errorf("sheetName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// Table is a go-pretty table that also keeps the rows appended to it, to write them as CSV and spreadsheets.
+type Table struct {
+	table.Writer
+	// Name is the name of the sheet in the xlsx format
+	Name string
+
+	header table.Row
+	rows   []table.Row
+	footer []table.Row
+}
+
+func NewTable() *Table {
+	return &Table{Writer: table.NewWriter()}
+}
+
+func (t *Table) AppendHeader(row table.Row, configs ...table.RowConfig) {
+	t.header = row
+	t.Writer.AppendHeader(row, configs...)
+}
+
+func (t *Table) AppendRow(row table.Row, configs ...table.RowConfig) {
+	t.rows = append(t.rows, row)
+	t.Writer.AppendRow(row, configs...)
+}
+
+func (t *Table) AppendRows(rows []table.Row, configs ...table.RowConfig) {
+	t.rows = append(t.rows, rows...)
+	t.Writer.AppendRows(rows, configs...)
+}
+
+func (t *Table) AppendFooter(row table.Row, configs ...table.RowConfig) {
+	t.footer = append(t.footer, row)
+	t.Writer.AppendFooter(row, configs...)
+}
+
+func (t *Table) write(w io.Writer, format Format) error {
+	var err error
+	switch format {
+	case CsvFormat:
+		return t.writeCsv(w)
+	case XlsxFormat:
+		return t.writeXlsx(w)
+	case MarkdownFormat:
+		_, err = fmt.Fprintln(w, t.RenderMarkdown())
+	case HtmlFormat:
+		_, err = fmt.Fprintln(w, t.RenderHTML())
+	default:
+		_, err = fmt.Fprintln(w, t.Render())
+	}
+
+	return err
+}
+
+// allRows returns the header, the rows and the footer of the table.
+func (t *Table) allRows() []table.Row {
+	rows := make([]table.Row, 0, len(t.rows)+len(t.footer)+1)
+	if t.header != nil {
+		rows = append(rows, t.header)
+	}
+
+	return append(append(rows, t.rows...), t.footer...)
+}
+
+func (t *Table) writeCsv(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	for _, row := range t.allRows() {
+		record := make([]string, len(row))
+		for i, cell := range row {
+			if cell != nil {
+				record[i] = fmt.Sprint(cell)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
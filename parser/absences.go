@@ -14,19 +14,52 @@ type AbsenceReport struct {
 	Courses     []CourseAbsence `json:"courses"`
 }
 
+// TermPeriods counts periods in each term of the academic year.
+type TermPeriods struct {
+	Ete   int `json:"ete"`
+	Term1 int `json:"term1"`
+	Term2 int `json:"term2"`
+	Term3 int `json:"term3"`
+	Term4 int `json:"term4"`
+}
+
+// Terms returns the counts in the order of the terms.
+func (p TermPeriods) Terms() []int {
+	return []int{p.Ete, p.Term1, p.Term2, p.Term3, p.Term4}
+}
+
 type CourseAbsence struct {
-	Name    string `json:"name"`
-	Periods struct {
-		Ete   int `json:"ete"`
-		Term1 int `json:"term1"`
-		Term2 int `json:"term2"`
-		Term3 int `json:"term3"`
-		Term4 int `json:"term4"`
-	} `json:"periods"`
-	Total           int `json:"total"`
-	Justified       int `json:"justified"`
-	RelativePeriods int `json:"relativePeriods"`
-	AbsolutePeriods int `json:"absolutePeriods"`
+	Name    string      `json:"name"`
+	Periods TermPeriods `json:"periods"`
+	// JustifiedPeriods are the justified periods of each term, Justified being their total
+	JustifiedPeriods TermPeriods `json:"justifiedPeriods"`
+	Total            int         `json:"total"`
+	Justified        int         `json:"justified"`
+	RelativePeriods  int         `json:"relativePeriods"`
+	AbsolutePeriods  int         `json:"absolutePeriods"`
+}
+
+// Unjustified returns the number of absent periods that were not justified.
+func (c *CourseAbsence) Unjustified() int {
+	return c.Total - c.Justified
+}
+
+// RelativeRate returns the unjustified absences as a percentage of the periods given so far.
+func (c *CourseAbsence) RelativeRate() float64 {
+	return absenceRate(c.Unjustified(), c.RelativePeriods)
+}
+
+// AbsoluteRate returns the unjustified absences as a percentage of all the periods of the course.
+func (c *CourseAbsence) AbsoluteRate() float64 {
+	return absenceRate(c.Unjustified(), c.AbsolutePeriods)
+}
+
+func absenceRate(absences int, periods int) float64 {
+	if periods == 0 {
+		return 0
+	}
+
+	return float64(absences) / float64(periods) * 100
 }
 
 func (s *Parser) Absences() (*AbsenceReport, error) {
@@ -51,11 +84,14 @@ func (s *Parser) Absences() (*AbsenceReport, error) {
 
 		cells := row.Find("td.b_cell")
 
-		course.Periods.Ete = parseAbsenceWithJustified(cells.Eq(0).Text(), &course.Justified)
-		course.Periods.Term1 = parseAbsenceWithJustified(cells.Eq(1).Text(), &course.Justified)
-		course.Periods.Term2 = parseAbsenceWithJustified(cells.Eq(2).Text(), &course.Justified)
-		course.Periods.Term3 = parseAbsenceWithJustified(cells.Eq(3).Text(), &course.Justified)
-		course.Periods.Term4 = parseAbsenceWithJustified(cells.Eq(4).Text(), &course.Justified)
+		course.Periods.Ete, course.JustifiedPeriods.Ete = parseAbsenceWithJustified(cells.Eq(0).Text())
+		course.Periods.Term1, course.JustifiedPeriods.Term1 = parseAbsenceWithJustified(cells.Eq(1).Text())
+		course.Periods.Term2, course.JustifiedPeriods.Term2 = parseAbsenceWithJustified(cells.Eq(2).Text())
+		course.Periods.Term3, course.JustifiedPeriods.Term3 = parseAbsenceWithJustified(cells.Eq(3).Text())
+		course.Periods.Term4, course.JustifiedPeriods.Term4 = parseAbsenceWithJustified(cells.Eq(4).Text())
+		for _, justified := range course.JustifiedPeriods.Terms() {
+			course.Justified += justified
+		}
 		course.Total = parseAbsence(cells.Eq(5).Text())
 
 		course.RelativePeriods = parseAbsence(cells.Eq(6).Text())
@@ -67,22 +103,22 @@ func (s *Parser) Absences() (*AbsenceReport, error) {
 	return report, nil
 }
 
-func parseAbsenceWithJustified(text string, totalJustified *int) int {
+// parseAbsenceWithJustified returns the periods and the justified periods of a term cell.
+func parseAbsenceWithJustified(text string) (int, int) {
 	text = strings.TrimSpace(text)
 	if text == "" || text == "&nbsp" {
-		return 0
+		return 0, 0
 	}
 
 	// Look for pattern like "2 [2]"
 	if matches := regexp.MustCompile(`(\d+)\s*\[(\d+)\]`).FindStringSubmatch(text); matches != nil {
 		justified, _ := strconv.Atoi(matches[2])
-		*totalJustified += justified
 		total, _ := strconv.Atoi(matches[1])
-		return total
+		return total, justified
 	}
 
 	num, _ := strconv.Atoi(text)
-	return num
+	return num, 0
 }
 
 func parseAbsence(text string) int {
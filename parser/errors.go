@@ -0,0 +1,27 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUnexpectedStructure is returned when a page does not match the structure expected by the parser,
+	// usually meaning that GAPS changed its HTML and the parser needs an update.
+	ErrUnexpectedStructure = errors.New("unexpected html structure")
+
+	UnknownReportCardStructure = structureError("unknown report card structure")
+)
+
+// SajaxError is returned when GAPS answers a sajax call with an error ("-:" prefixed) response.
+type SajaxError struct {
+	Message string
+}
+
+func (e *SajaxError) Error() string {
+	return "sajax raised error: " + e.Message
+}
+
+func structureError(format string, a ...any) error {
+	return fmt.Errorf("%w: %s", ErrUnexpectedStructure, fmt.Sprintf(format, a...))
+}
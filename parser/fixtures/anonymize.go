@@ -0,0 +1,195 @@
+package fixtures
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html/charset"
+	"lutonite.dev/gaps-cli/parser"
+)
+
+const anonymous = "Anonyme"
+
+// gradeCount is the number of grades from 1.0 to 6.0
+const gradeCount = 51
+
+// ErrFixtureExists is returned by Create when the fixture would overwrite an existing one.
+var ErrFixtureExists = errors.New("fixture already exists")
+
+var (
+	// the part of each page the parsers read, everything else is dropped from fixtures
+	kindSelectors = map[Kind]string{
+		Grades:     "table.displayArray",
+		ReportCard: "table#record_table",
+		Absences:   "table:has(tr.a_r_0)",
+	}
+
+	studentIdRegex = regexp.MustCompile(`const DEFAULT_STUDENT_ID = \d+;`)
+	gradeRegex     = regexp.MustCompile(`(seuil : )?\b[1-6]\.\d\b`)
+)
+
+// AnonymizeOptions controls how much of a captured page is changed when turning it into a fixture.
+type AnonymizeOptions struct {
+	// ScrambleGrades replaces every grade-looking value through a random permutation of the grades,
+	// drawn for each page and never stored so that the original grades can't be recovered
+	ScrambleGrades bool
+	// Replace lists additional literal values to remove, e.g. the student or teachers names
+	Replace []string
+}
+
+// ReadCapture reads a page as captured from GAPS: either a capture file written by --record,
+// a raw sajax reply, or a plain HTML page in UTF-8 or ISO-8859-1.
+func ReadCapture(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var capture struct {
+		Response *struct {
+			Header   map[string][]string `json:"header"`
+			Body     string              `json:"body"`
+			Encoding string              `json:"encoding"`
+		} `json:"response"`
+	}
+	if json.Unmarshal(data, &capture) == nil && capture.Response != nil {
+		data = []byte(capture.Response.Body)
+		if capture.Response.Encoding == "base64" {
+			if data, err = base64.StdEncoding.DecodeString(capture.Response.Body); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	reader, err := charset.NewReader(strings.NewReader(string(data)), "")
+	if err != nil {
+		return "", err
+	}
+
+	page, err := io.ReadAll(reader)
+	return string(page), err
+}
+
+// Anonymize turns a captured page into a fixture: sajax wrapping is removed, only the part of the
+// page read by the parser is kept, scripts are dropped and personal values are replaced.
+func Anonymize(kind Kind, page string, opts AnonymizeOptions) (string, error) {
+	// sajax errors are not worth a fixture
+	if _, err := parser.FromString(page); err != nil {
+		return "", err
+	}
+
+	page = unwrap(page)
+
+	for _, value := range opts.Replace {
+		if value != "" {
+			page = strings.ReplaceAll(page, value, anonymous)
+		}
+	}
+
+	if kind == StudentId {
+		if !studentIdRegex.MatchString(page) {
+			return "", fmt.Errorf("no student id found in page")
+		}
+
+		return "<script type=\"text/javascript\">\n\tconst DEFAULT_STUDENT_ID = 12345;\n</script>\n", nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		return "", err
+	}
+
+	doc.Find("script, style, link, meta").Remove()
+	doc.Find(".s_cell").Each(func(i int, s *goquery.Selection) {
+		s.SetText(anonymous)
+	})
+
+	var sb strings.Builder
+	doc.Find(kindSelectors[kind]).Each(func(i int, s *goquery.Selection) {
+		table, err := goquery.OuterHtml(s)
+		if err == nil {
+			sb.WriteString(table)
+			sb.WriteString("\n")
+		}
+	})
+
+	if sb.Len() == 0 {
+		return "", fmt.Errorf("no %s table found in page", kind)
+	}
+
+	fixture := sb.String()
+	if opts.ScrambleGrades {
+		fixture = gradeRegex.ReplaceAllStringFunc(fixture, gradeScrambler(rand.Perm(gradeCount)))
+	}
+
+	return fixture, nil
+}
+
+// unwrap removes the sajax envelope of a reply, keeping only the replaced part of smart replies.
+func unwrap(text string) string {
+	text = strings.TrimPrefix(text, "+:")
+	if strings.HasPrefix(text, "\"") {
+		_ = json.Unmarshal([]byte(text), &text)
+	}
+
+	if parts := strings.SplitN(text, "@££@", 3); len(parts) > 1 {
+		text = parts[1]
+	}
+
+	return text
+}
+
+// gradeScrambler maps each grade from 1.0 to 6.0 to the grade at the same index of the permutation.
+func gradeScrambler(permutation []int) func(grade string) string {
+	return func(grade string) string {
+		// passing grades are not personal
+		if strings.HasPrefix(grade, "seuil") {
+			return grade
+		}
+
+		tenths := int(grade[0]-'0')*10 + int(grade[2]-'0') - 10
+		if tenths >= len(permutation) {
+			return grade
+		}
+
+		scrambled := permutation[tenths] + 10
+		return fmt.Sprintf("%d.%d", scrambled/10, scrambled%10)
+	}
+}
+
+// Create anonymizes a captured page into a new fixture of the corpus and writes its golden file, an existing
+// fixture of the same name is only replaced when forced.
+func Create(dir string, kind Kind, name string, page string, opts AnonymizeOptions, force bool) (*Fixture, error) {
+	fixture := &Fixture{Kind: kind, Name: name, Dir: dir}
+	if !force {
+		for _, path := range []string{fixture.PagePath(), fixture.GoldenPath()} {
+			if _, err := os.Stat(path); err == nil {
+				return nil, fmt.Errorf("%w: %s", ErrFixtureExists, path)
+			}
+		}
+	}
+
+	content, err := Anonymize(kind, page, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fixture.PagePath()), 0755); err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(fixture.PagePath(), []byte(content), 0644); err != nil {
+		return nil, err
+	}
+
+	return fixture, fixture.UpdateGolden()
+}
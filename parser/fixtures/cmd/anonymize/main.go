@@ -0,0 +1,56 @@
+// Command anonymize turns a captured GAPS page (raw page, sajax reply or --record capture) into a new fixture
+// of the parser corpus, along with its golden file:
+//
+//	go run ./parser/fixtures/cmd/anonymize -kind grades -name my-case capture.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"lutonite.dev/gaps-cli/parser/fixtures"
+)
+
+func main() {
+	kind := fixtures.Grades
+	dir := flag.String("dir", "parser/testdata", "Fixture corpus directory")
+	name := flag.String("name", "", "Fixture name")
+	scramble := flag.Bool("scramble-grades", false, "Replace grades through a random permutation")
+	replace := flag.String("replace", "", "Comma separated additional values to anonymize (names, emails, ...)")
+	force := flag.Bool("force", false, "Overwrite an existing fixture of the same name")
+	flag.Var(&kind, "kind", fmt.Sprintf("Page kind %v", fixtures.Kinds))
+	flag.Parse()
+
+	if *name == "" || flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: anonymize -name <name> [-kind <kind>] [flags] <capture>")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	page, err := fixtures.ReadCapture(flag.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+
+	var values []string
+	if *replace != "" {
+		values = strings.Split(*replace, ",")
+	}
+
+	fixture, err := fixtures.Create(*dir, kind, *name, page, fixtures.AnonymizeOptions{
+		ScrambleGrades: *scramble,
+		Replace:        values,
+	}, *force)
+	if err != nil {
+		fail(err)
+	}
+
+	fmt.Printf("Created %s and %s, review them for personal data before committing\n", fixture.PagePath(), fixture.GoldenPath())
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
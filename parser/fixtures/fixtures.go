@@ -0,0 +1,202 @@
+// Package fixtures manages the corpus of GAPS pages used to catch parser regressions: every page is
+// stored next to the golden JSON of its parsed content, and captured pages can be anonymized into new fixtures.
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"lutonite.dev/gaps-cli/parser"
+)
+
+// Kind is the type of GAPS page a fixture holds, fixtures are stored in a directory named after it.
+type Kind string
+
+const (
+	Grades     Kind = "grades"
+	ReportCard Kind = "report-card"
+	Absences   Kind = "absences"
+	StudentId  Kind = "student-id"
+
+	pageExt   = ".html"
+	goldenExt = ".golden.json"
+)
+
+var Kinds = []Kind{Grades, ReportCard, Absences, StudentId}
+
+func (k Kind) String() string {
+	return string(k)
+}
+
+func (k *Kind) Set(s string) error {
+	for _, kind := range Kinds {
+		if string(kind) == s {
+			*k = kind
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid fixture kind: %s. Must be one of: %v", s, Kinds)
+}
+
+func (k Kind) Type() string {
+	return "Kind"
+}
+
+// Fixture is a single page of the corpus.
+type Fixture struct {
+	Kind Kind
+	Name string
+	Dir  string
+}
+
+func (f *Fixture) PagePath() string {
+	return filepath.Join(f.Dir, string(f.Kind), f.Name+pageExt)
+}
+
+func (f *Fixture) GoldenPath() string {
+	return filepath.Join(f.Dir, string(f.Kind), f.Name+goldenExt)
+}
+
+// Result is the outcome of checking a fixture against its golden file.
+type Result struct {
+	Fixture *Fixture
+	// Diff is empty when the parsed page matches the golden file
+	Diff string
+	Err  error
+}
+
+func (r *Result) Ok() bool {
+	return r.Err == nil && r.Diff == ""
+}
+
+// golden is what is stored in golden files: the parsed page, or the error the parser is expected to return.
+type golden struct {
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Parse runs the parser matching the fixture kind on the given page.
+func Parse(kind Kind, page string) (any, error) {
+	p, err := parser.FromString(page)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case Grades:
+		return p.Grades()
+	case ReportCard:
+		return p.ReportCard()
+	case Absences:
+		return p.Absences()
+	case StudentId:
+		return p.StudentId()
+	default:
+		return nil, fmt.Errorf("unknown fixture kind %s", kind)
+	}
+}
+
+// List returns every fixture of the corpus rooted at dir, sorted by kind and name.
+func List(dir string) ([]*Fixture, error) {
+	var fixtures []*Fixture
+	for _, kind := range Kinds {
+		pages, err := filepath.Glob(filepath.Join(dir, string(kind), "*"+pageExt))
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Strings(pages)
+		for _, page := range pages {
+			fixtures = append(fixtures, &Fixture{
+				Kind: kind,
+				Name: strings.TrimSuffix(filepath.Base(page), pageExt),
+				Dir:  dir,
+			})
+		}
+	}
+
+	return fixtures, nil
+}
+
+// Verify parses the fixture page and compares the result with its golden file.
+func (f *Fixture) Verify() *Result {
+	actual, err := f.render()
+	if err != nil {
+		return &Result{Fixture: f, Err: err}
+	}
+
+	expected, err := os.ReadFile(f.GoldenPath())
+	if err != nil {
+		return &Result{Fixture: f, Err: err}
+	}
+
+	return &Result{Fixture: f, Diff: diffLines(string(expected), string(actual))}
+}
+
+// UpdateGolden parses the fixture page and overwrites its golden file with the result.
+func (f *Fixture) UpdateGolden() error {
+	actual, err := f.render()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(f.GoldenPath(), actual, 0644)
+}
+
+func (f *Fixture) render() ([]byte, error) {
+	page, err := os.ReadFile(f.PagePath())
+	if err != nil {
+		return nil, err
+	}
+
+	g := golden{}
+	g.Result, err = Parse(f.Kind, string(page))
+	if err != nil {
+		g.Result = nil
+		g.Error = err.Error()
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "\t")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(g); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// diffLines returns the lines that differ between expected and actual, prefixed like a unified diff,
+// which is enough to locate the broken field in indented JSON.
+func diffLines(expected string, actual string) string {
+	if expected == actual {
+		return ""
+	}
+
+	el := strings.Split(expected, "\n")
+	al := strings.Split(actual, "\n")
+
+	var sb strings.Builder
+	for i := 0; i < len(el) || i < len(al); i++ {
+		var e, a string
+		if i < len(el) {
+			e = el[i]
+		}
+		if i < len(al) {
+			a = al[i]
+		}
+
+		if e != a {
+			fmt.Fprintf(&sb, "@@ line %d @@\n-%s\n+%s\n", i+1, e, a)
+		}
+	}
+
+	return sb.String()
+}
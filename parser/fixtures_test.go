@@ -0,0 +1,42 @@
+package parser_test
+
+import (
+	"flag"
+	"testing"
+
+	"lutonite.dev/gaps-cli/parser/fixtures"
+)
+
+var update = flag.Bool("update", false, "overwrite the golden files with the current parser output")
+
+// TestFixtures parses every page of the corpus in testdata and compares the result with its golden file.
+func TestFixtures(t *testing.T) {
+	list, err := fixtures.List("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) == 0 {
+		t.Fatal("no fixture found in testdata")
+	}
+
+	for _, fixture := range list {
+		fixture := fixture
+		t.Run(string(fixture.Kind)+"/"+fixture.Name, func(t *testing.T) {
+			if *update {
+				if err := fixture.UpdateGolden(); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			result := fixture.Verify()
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+			if result.Diff != "" {
+				t.Errorf("parsed page doesn't match %s, run go test ./parser -update if the change is expected:\n%s",
+					fixture.GoldenPath(), result.Diff)
+			}
+		})
+	}
+}
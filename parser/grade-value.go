@@ -0,0 +1,190 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// GradeState tells whether a GradeValue holds an actual grade.
+type GradeState int
+
+const (
+	// NotGraded is the state of grades GAPS shows as "-" or leaves empty
+	NotGraded GradeState = iota
+	Graded
+	// Exempt is the state of grades the student was dispensed from
+	Exempt
+)
+
+const exemptJSON = "exempt"
+
+// GradeValue is the numeric counterpart of the raw grade strings found on GAPS pages,
+// making "not yet graded" and "exempt" explicit instead of silently parsing them to 0.
+type GradeValue struct {
+	value float64
+	state GradeState
+}
+
+// NewGradeValue returns a graded value.
+func NewGradeValue(value float64) GradeValue {
+	return GradeValue{value: value, state: Graded}
+}
+
+// ExemptGrade returns an exempt value.
+func ExemptGrade() GradeValue {
+	return GradeValue{state: Exempt}
+}
+
+// ParseGradeValue converts a grade as displayed by GAPS, unknown values are considered not graded.
+func ParseGradeValue(text string) GradeValue {
+	text = strings.TrimSpace(text)
+	lower := strings.ToLower(text)
+	if strings.HasPrefix(lower, "disp") || lower == exemptJSON {
+		return ExemptGrade()
+	}
+
+	value, err := strconv.ParseFloat(strings.Replace(text, ",", ".", 1), 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return GradeValue{}
+	}
+
+	return NewGradeValue(value)
+}
+
+func (g GradeValue) State() GradeState {
+	return g.state
+}
+
+func (g GradeValue) IsGraded() bool {
+	return g.state == Graded
+}
+
+func (g GradeValue) IsExempt() bool {
+	return g.state == Exempt
+}
+
+// Float returns the grade and whether it is an actual grade.
+func (g GradeValue) Float() (float64, bool) {
+	return g.value, g.state == Graded
+}
+
+// Add sums two grades, the result is only graded when both are.
+func (g GradeValue) Add(other GradeValue) GradeValue {
+	if !g.IsGraded() || !other.IsGraded() {
+		return GradeValue{}
+	}
+
+	return NewGradeValue(g.value + other.value)
+}
+
+// Sub subtracts other from the grade, the result is only graded when both are.
+func (g GradeValue) Sub(other GradeValue) GradeValue {
+	if !g.IsGraded() || !other.IsGraded() {
+		return GradeValue{}
+	}
+
+	return NewGradeValue(g.value - other.value)
+}
+
+// Mul scales the grade, keeping its state.
+func (g GradeValue) Mul(factor float64) GradeValue {
+	return GradeValue{value: g.value * factor, state: g.state}
+}
+
+// Round rounds the grade to the nearest multiple of step, e.g. 0.1 or 0.5.
+func (g GradeValue) Round(step float64) GradeValue {
+	if !g.IsGraded() || step <= 0 {
+		return g
+	}
+
+	return NewGradeValue(math.Round(g.value/step) * step)
+}
+
+// Clamp bounds the grade to the given range, GAPS grades go from 1 to 6.
+func (g GradeValue) Clamp(min float64, max float64) GradeValue {
+	if !g.IsGraded() {
+		return g
+	}
+
+	return NewGradeValue(math.Max(min, math.Min(max, g.value)))
+}
+
+// WeightedMean averages the graded values with their weights, ignoring the values not graded or exempt
+// as GAPS does. The result is not graded when no value is.
+func WeightedMean(values []GradeValue, weights []float64) GradeValue {
+	var sum, totalWeight float64
+	for i, value := range values {
+		if !value.IsGraded() || i >= len(weights) {
+			continue
+		}
+
+		sum += value.value * weights[i]
+		totalWeight += weights[i]
+	}
+
+	if totalWeight == 0 {
+		return GradeValue{}
+	}
+
+	return NewGradeValue(sum / totalWeight)
+}
+
+// String formats the grade the way GAPS displays it.
+func (g GradeValue) String() string {
+	switch g.state {
+	case Graded:
+		text := strconv.FormatFloat(g.value, 'f', -1, 64)
+		if !strings.Contains(text, ".") {
+			text += ".0"
+		}
+		return text
+	case Exempt:
+		return "disp."
+	default:
+		return "-"
+	}
+}
+
+// Format formats the grade with the given number of decimals, keeping "-" for values not graded.
+func (g GradeValue) Format(decimals int) string {
+	if !g.IsGraded() {
+		return g.String()
+	}
+
+	return strconv.FormatFloat(g.value, 'f', decimals, 64)
+}
+
+// MarshalJSON encodes graded values as numbers, values not graded as null and exempt ones as "exempt".
+func (g GradeValue) MarshalJSON() ([]byte, error) {
+	switch g.state {
+	case Graded:
+		return json.Marshal(g.value)
+	case Exempt:
+		return json.Marshal(exemptJSON)
+	default:
+		return []byte("null"), nil
+	}
+}
+
+func (g *GradeValue) UnmarshalJSON(data []byte) error {
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	switch v := raw.(type) {
+	case nil:
+		*g = GradeValue{}
+	case float64:
+		*g = NewGradeValue(v)
+	case string:
+		*g = ParseGradeValue(v)
+	default:
+		return fmt.Errorf("invalid grade value: %s", data)
+	}
+
+	return nil
+}
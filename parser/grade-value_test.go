@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseGradeValue(t *testing.T) {
+	tests := []struct {
+		text  string
+		state GradeState
+		value float64
+	}{
+		{"4.5", Graded, 4.5},
+		{" 5 ", Graded, 5},
+		{"4,5", Graded, 4.5},
+		{"1.0", Graded, 1},
+		{"-", NotGraded, 0},
+		{"", NotGraded, 0},
+		{"abc", NotGraded, 0},
+		{"NaN", NotGraded, 0},
+		{"Inf", NotGraded, 0},
+		{"disp.", Exempt, 0},
+		{"Dispensé", Exempt, 0},
+		{"exempt", Exempt, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			g := ParseGradeValue(tt.text)
+			if g.State() != tt.state {
+				t.Fatalf("state = %d, want %d", g.State(), tt.state)
+			}
+
+			value, ok := g.Float()
+			if ok != (tt.state == Graded) || value != tt.value {
+				t.Errorf("Float() = %v, %t, want %v, %t", value, ok, tt.value, tt.state == Graded)
+			}
+		})
+	}
+}
+
+func TestGradeValueJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		value GradeValue
+		json  string
+	}{
+		{"graded", NewGradeValue(4.5), `4.5`},
+		{"not graded", GradeValue{}, `null`},
+		{"exempt", ExemptGrade(), `"exempt"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.json {
+				t.Fatalf("Marshal = %s, want %s", data, tt.json)
+			}
+
+			var decoded GradeValue
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if decoded != tt.value {
+				t.Errorf("Unmarshal(%s) = %+v, want %+v", data, decoded, tt.value)
+			}
+		})
+	}
+
+	var g GradeValue
+	if err := json.Unmarshal([]byte(`"5.5"`), &g); err != nil || g != NewGradeValue(5.5) {
+		t.Errorf(`Unmarshal("5.5") = %+v, %v, want 5.5`, g, err)
+	}
+	if err := json.Unmarshal([]byte(`true`), &g); err == nil {
+		t.Error("Unmarshal(true) succeeded, want an error")
+	}
+}
+
+func TestGradeValueArithmetic(t *testing.T) {
+	graded := NewGradeValue(4)
+	tests := []struct {
+		name string
+		got  GradeValue
+		want GradeValue
+	}{
+		{"add", graded.Add(NewGradeValue(1.5)), NewGradeValue(5.5)},
+		{"add not graded", graded.Add(GradeValue{}), GradeValue{}},
+		{"add exempt", ExemptGrade().Add(graded), GradeValue{}},
+		{"sub", graded.Sub(NewGradeValue(1.5)), NewGradeValue(2.5)},
+		{"sub not graded", GradeValue{}.Sub(graded), GradeValue{}},
+		{"mul", graded.Mul(0.5), NewGradeValue(2)},
+		{"mul exempt", ExemptGrade().Mul(2), ExemptGrade()},
+		{"round tenth", NewGradeValue(4.46).Round(0.1), NewGradeValue(4.5)},
+		{"round half", NewGradeValue(4.3).Round(0.5), NewGradeValue(4.5)},
+		{"round invalid step", NewGradeValue(4.3).Round(0), NewGradeValue(4.3)},
+		{"round not graded", GradeValue{}.Round(0.5), GradeValue{}},
+		{"clamp low", NewGradeValue(0.2).Clamp(1, 6), NewGradeValue(1)},
+		{"clamp high", NewGradeValue(7).Clamp(1, 6), NewGradeValue(6)},
+		{"clamp within", NewGradeValue(4.2).Clamp(1, 6), NewGradeValue(4.2)},
+		{"clamp exempt", ExemptGrade().Clamp(1, 6), ExemptGrade()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeightedMean(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []GradeValue
+		weights []float64
+		want    GradeValue
+	}{
+		{"weighted", []GradeValue{NewGradeValue(4), NewGradeValue(6)}, []float64{3, 1}, NewGradeValue(4.5)},
+		{"ignores not graded", []GradeValue{NewGradeValue(4), {}}, []float64{1, 1}, NewGradeValue(4)},
+		{"ignores exempt", []GradeValue{ExemptGrade(), NewGradeValue(5)}, []float64{1, 1}, NewGradeValue(5)},
+		{"ignores missing weights", []GradeValue{NewGradeValue(4), NewGradeValue(6)}, []float64{1}, NewGradeValue(4)},
+		{"nothing graded", []GradeValue{{}, ExemptGrade()}, []float64{1, 1}, GradeValue{}},
+		{"zero weights", []GradeValue{NewGradeValue(4)}, []float64{0}, GradeValue{}},
+		{"empty", nil, nil, GradeValue{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WeightedMean(tt.values, tt.weights); got != tt.want {
+				t.Errorf("WeightedMean = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGradeValueFormat(t *testing.T) {
+	tests := []struct {
+		value    GradeValue
+		str      string
+		decimals string
+	}{
+		{NewGradeValue(5), "5.0", "5.00"},
+		{NewGradeValue(4.25), "4.25", "4.25"},
+		{GradeValue{}, "-", "-"},
+		{ExemptGrade(), "disp.", "disp."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			if got := tt.value.String(); got != tt.str {
+				t.Errorf("String() = %s, want %s", got, tt.str)
+			}
+			if got := tt.value.Format(2); got != tt.decimals {
+				t.Errorf("Format(2) = %s, want %s", got, tt.decimals)
+			}
+		})
+	}
+}
@@ -1,8 +1,6 @@
 package parser
 
 import (
-	"errors"
-	"fmt"
 	"github.com/PuerkitoBio/goquery"
 	log "github.com/sirupsen/logrus"
 	"regexp"
@@ -12,25 +10,29 @@ import (
 )
 
 type ClassGrades struct {
-	Name        string        `json:"name"`
-	GlobalMean  string        `json:"globalMean"`
-	HasExam     bool          `json:"hasExam"`
-	GradeGroups []*GradeGroup `json:"gradeGroups"`
+	Name            string        `json:"name"`
+	GlobalMean      string        `json:"globalMean"`
+	GlobalMeanValue GradeValue    `json:"globalMeanValue"`
+	HasExam         bool          `json:"hasExam"`
+	GradeGroups     []*GradeGroup `json:"gradeGroups"`
 }
 
 type GradeGroup struct {
-	Name   string   `json:"name"`
-	Mean   string   `json:"mean"`
-	Weight uint     `json:"weight"`
-	Grades []*Grade `json:"grades"`
+	Name      string     `json:"name"`
+	Mean      string     `json:"mean"`
+	MeanValue GradeValue `json:"meanValue"`
+	Weight    uint       `json:"weight"`
+	Grades    []*Grade   `json:"grades"`
 }
 
 type Grade struct {
-	Description string    `json:"description"`
-	Date        time.Time `json:"date"`
-	Weight      float32   `json:"weight"`
-	Grade       string    `json:"grade"`
-	ClassMean   string    `json:"classMean"`
+	Description    string     `json:"description"`
+	Date           time.Time  `json:"date"`
+	Weight         float32    `json:"weight"`
+	Grade          string     `json:"grade"`
+	Value          GradeValue `json:"value"`
+	ClassMean      string     `json:"classMean"`
+	ClassMeanValue GradeValue `json:"classMeanValue"`
 }
 
 type gradeRowType int
@@ -101,7 +103,7 @@ func (p gradesParser) parse() ([]*ClassGrades, error) {
 				grade,
 			)
 		default:
-			globalErr = errors.New("unknown row type")
+			globalErr = structureError("unknown row type")
 			log.Debugf("unknown row with content:\n%s", s.Text())
 		}
 	})
@@ -131,13 +133,14 @@ func (p gradesParser) parseClassHeader(row *goquery.Selection) (*ClassGrades, er
 
 	matches := re.FindStringSubmatch(text)
 	if len(matches) != 4 {
-		return nil, fmt.Errorf("could not parse class header")
+		return nil, structureError("could not parse class header")
 	}
 
 	return &ClassGrades{
-		Name:       matches[1],
-		GlobalMean: matches[3],
-		HasExam:    matches[2] != "",
+		Name:            matches[1],
+		GlobalMean:      matches[3],
+		GlobalMeanValue: ParseGradeValue(matches[3]),
+		HasExam:         matches[2] != "",
 	}, nil
 }
 
@@ -154,7 +157,7 @@ func (p gradesParser) parseGroupHeader(row *goquery.Selection) (*GradeGroup, err
 
 	matches := re.FindStringSubmatch(text)
 	if len(matches) != 4 {
-		return nil, fmt.Errorf("could not parse class header")
+		return nil, structureError("could not parse group header")
 	}
 
 	weight, err := strconv.ParseUint(matches[3], 10, 32)
@@ -163,9 +166,10 @@ func (p gradesParser) parseGroupHeader(row *goquery.Selection) (*GradeGroup, err
 	}
 
 	return &GradeGroup{
-		Name:   matches[1],
-		Mean:   matches[2],
-		Weight: uint(weight),
+		Name:      matches[1],
+		Mean:      matches[2],
+		MeanValue: ParseGradeValue(matches[2]),
+		Weight:    uint(weight),
 	}, nil
 }
 
@@ -188,11 +192,13 @@ func (p gradesParser) parseGradeRow(row *goquery.Selection) (*Grade, error) {
 	}
 
 	return &Grade{
-		Description: p.parseDescription(descriptionCell),
-		Date:        date,
-		Weight:      weight,
-		Grade:       gradeCell.Text(),
-		ClassMean:   meanCell.Text(),
+		Description:    p.parseDescription(descriptionCell),
+		Date:           date,
+		Weight:         weight,
+		Grade:          gradeCell.Text(),
+		Value:          ParseGradeValue(gradeCell.Text()),
+		ClassMean:      meanCell.Text(),
+		ClassMeanValue: ParseGradeValue(meanCell.Text()),
 	}, nil
 }
 
@@ -204,7 +210,7 @@ func (p gradesParser) parseWeight(weightCell *goquery.Selection) (float32, error
 
 	matches := re.FindStringSubmatch(weightCell.Text())
 	if len(matches) != 2 {
-		return 0, fmt.Errorf("could not parse weight")
+		return 0, structureError("could not parse weight")
 	}
 
 	weight, err := strconv.ParseFloat(matches[1], 32)
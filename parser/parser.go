@@ -2,7 +2,6 @@ package parser
 
 import (
 	"encoding/json"
-	"errors"
 	"io"
 	"strings"
 )
@@ -26,7 +25,7 @@ func FromResponseBody(reader io.Reader) (*Parser, error) {
 
 func FromString(text string) (*Parser, error) {
 	if strings.HasPrefix(text, "-:") {
-		return nil, errors.New("sajax raised error:" + text[2:])
+		return nil, &SajaxError{Message: text[2:]}
 	}
 
 	if strings.HasPrefix(text, "+:") {
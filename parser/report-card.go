@@ -1,21 +1,22 @@
 package parser
 
 import (
-	"errors"
 	"github.com/PuerkitoBio/goquery"
 	"strconv"
 	"strings"
 )
 
 type ModuleReport struct {
-	Identifier   string         `json:"id"`
-	Name         string         `json:"name"`
-	Year         uint           `json:"year"`
-	PassingGrade string         `json:"passingGrade"`
-	GlobalGrade  string         `json:"grade"`
-	Credits      uint           `json:"credits"`
-	Situation    string         `json:"situation"`
-	Classes      []*ModuleClass `json:"classes"`
+	Identifier        string         `json:"id"`
+	Name              string         `json:"name"`
+	Year              uint           `json:"year"`
+	PassingGrade      string         `json:"passingGrade"`
+	PassingGradeValue GradeValue     `json:"passingGradeValue"`
+	GlobalGrade       string         `json:"grade"`
+	GlobalGradeValue  GradeValue     `json:"gradeValue"`
+	Credits           uint           `json:"credits"`
+	Situation         string         `json:"situation"`
+	Classes           []*ModuleClass `json:"classes"`
 }
 
 type ModuleClass struct {
@@ -23,13 +24,15 @@ type ModuleClass struct {
 	Name       string        `json:"name"`
 	Grades     []*ClassGrade `json:"grades"`
 	Mean       string        `json:"mean"`
+	MeanValue  GradeValue    `json:"meanValue"`
 	Weight     uint          `json:"weight"`
 }
 
 type ClassGrade struct {
-	Name   string `json:"name"`
-	Weight uint   `json:"weight"`
-	Grade  string `json:"grade"`
+	Name   string     `json:"name"`
+	Weight uint       `json:"weight"`
+	Grade  string     `json:"grade"`
+	Value  GradeValue `json:"value"`
 }
 
 type reportCardRowType int
@@ -47,10 +50,6 @@ const (
 	reportCardUnknownRow = -1
 )
 
-var (
-	UnknownReportCardStructure = errors.New("unknown report card structure")
-)
-
 func (s *Parser) ReportCard() ([]*ModuleReport, error) {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(s.src))
 	if err != nil {
@@ -94,6 +93,11 @@ func (p reportCardParser) parse() ([]*ModuleReport, error) {
 			}
 
 			moduleOff := len(reports) - 1
+			if moduleOff < 0 {
+				globalErr = structureError("unit row found before any module row")
+				return
+			}
+
 			reports[moduleOff].Classes = append(reports[moduleOff].Classes, class)
 		}
 	})
@@ -121,6 +125,10 @@ func (p reportCardParser) parseModuleRow(row *goquery.Selection) (*ModuleReport,
 
 	nameText := row.Find("td").Eq(1).Text()
 	nameSplit := strings.SplitN(nameText, id, 2)
+	if id == "" || len(nameSplit) != 2 || len(nameSplit[0]) == 0 || len(nameSplit[1]) < len(") [seuil : ") {
+		return nil, structureError("could not parse module row")
+	}
+
 	name := strings.TrimSpace(nameSplit[0][:len(nameSplit[0])-1])
 	passingGrade := strings.TrimSpace(nameSplit[1][len(") [seuil : ") : len(nameSplit[1])-1])
 
@@ -136,13 +144,15 @@ func (p reportCardParser) parseModuleRow(row *goquery.Selection) (*ModuleReport,
 	}
 
 	return &ModuleReport{
-		Identifier:   id,
-		Name:         name,
-		Year:         uint(year),
-		PassingGrade: passingGrade,
-		GlobalGrade:  grade,
-		Credits:      uint(credits),
-		Situation:    situation,
+		Identifier:        id,
+		Name:              name,
+		Year:              uint(year),
+		PassingGrade:      passingGrade,
+		PassingGradeValue: ParseGradeValue(passingGrade),
+		GlobalGrade:       grade,
+		GlobalGradeValue:  ParseGradeValue(grade),
+		Credits:           uint(credits),
+		Situation:         situation,
 	}, nil
 }
 
@@ -167,6 +177,7 @@ func (p reportCardParser) parseUnitRow(row *goquery.Selection) (*ModuleClass, er
 			Name:   name,
 			Weight: uint(weight),
 			Grade:  grade,
+			Value:  ParseGradeValue(grade),
 		})
 	}
 
@@ -179,6 +190,7 @@ func (p reportCardParser) parseUnitRow(row *goquery.Selection) (*ModuleClass, er
 		Name:       className,
 		Grades:     grades,
 		Mean:       mean,
+		MeanValue:  ParseGradeValue(mean),
 		Weight:     uint(weight),
 	}, nil
 }
@@ -1,7 +1,6 @@
 package parser
 
 import (
-	"fmt"
 	"regexp"
 	"strconv"
 )
@@ -14,7 +13,7 @@ func (s *Parser) StudentId() (uint, error) {
 
 	matches := re.FindStringSubmatch(s.src)
 	if len(matches) != 2 {
-		return 0, fmt.Errorf("could not find student id in javascript")
+		return 0, structureError("could not find student id in javascript")
 	}
 
 	res, err := strconv.ParseUint(matches[1], 10, 32)
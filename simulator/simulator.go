@@ -0,0 +1,285 @@
+// Package simulator computes what-if scenarios on the grades of a class, adding hypothetical grades
+// and working out the grade needed on the remaining assessments to reach a target mean.
+package simulator
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"lutonite.dev/gaps-cli/parser"
+)
+
+const (
+	MinGrade     = 1.0
+	MaxGrade     = 6.0
+	PassingGrade = 4.0
+)
+
+// Hypothesis is a grade added to a group of the simulated class, a grade not graded stands
+// for a remaining assessment whose grade has to be solved for.
+type Hypothesis struct {
+	Group       string            `json:"group"`
+	Description string            `json:"description"`
+	Grade       parser.GradeValue `json:"grade"`
+	Weight      float64           `json:"weight"`
+}
+
+// ParseHypothesis reads a hypothesis written as <group>:<grade|?>:<weight>[:<description>].
+func ParseHypothesis(text string) (Hypothesis, error) {
+	parts := strings.SplitN(text, ":", 4)
+	if len(parts) < 3 {
+		return Hypothesis{}, fmt.Errorf("invalid grade %q, expected <group>:<grade|?>:<weight>[:<description>]", text)
+	}
+
+	h := Hypothesis{
+		Group:       strings.TrimSpace(parts[0]),
+		Description: "Simulated",
+	}
+
+	if grade := strings.TrimSpace(parts[1]); grade != "?" {
+		h.Grade = parser.ParseGradeValue(grade)
+		if !h.Grade.IsGraded() {
+			return Hypothesis{}, fmt.Errorf("invalid grade %q", grade)
+		}
+	}
+
+	weight, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(parts[2]), "%"), 64)
+	if err != nil || weight <= 0 {
+		return Hypothesis{}, fmt.Errorf("invalid weight %q", parts[2])
+	}
+	h.Weight = weight
+
+	if len(parts) == 4 {
+		h.Description = strings.TrimSpace(parts[3])
+	}
+
+	return h, nil
+}
+
+// Options of a simulation.
+type Options struct {
+	// Exam is the exam grade of classes with an exam, the exam is a remaining assessment when not graded
+	Exam parser.GradeValue
+	// ExamWeight is the share of the exam in the final grade, from 0 to 1
+	ExamWeight float64
+	// Target is the final grade to reach, PassingGrade when zero
+	Target float64
+	// IgnorePending excludes the grades listed by GAPS but not graded yet from the remaining assessments
+	IgnorePending bool
+}
+
+// GroupResult is the simulated state of a grade group.
+type GroupResult struct {
+	Name       string            `json:"name"`
+	Weight     uint              `json:"weight"`
+	Mean       parser.GradeValue `json:"mean"`
+	Pending    int               `json:"pending"`
+	Simulated  int               `json:"simulated"`
+	Hypotheses []Hypothesis      `json:"hypotheses,omitempty"`
+}
+
+// Result of a simulation, means are computed with every remaining assessment graded Required.
+type Result struct {
+	Class   string         `json:"class"`
+	HasExam bool           `json:"hasExam"`
+	Groups  []*GroupResult `json:"groups"`
+	// Mean is the continuous assessment mean (without exam)
+	Mean parser.GradeValue `json:"mean"`
+	// Final is the class grade, including the exam when the class has one
+	Final  parser.GradeValue `json:"final"`
+	Target float64           `json:"target"`
+	// Remaining is the number of assessments without a grade yet
+	Remaining int `json:"remaining"`
+	// Required is the minimum grade to get on every remaining assessment to reach the target,
+	// not graded when nothing remains
+	Required parser.GradeValue `json:"required"`
+	// Reachable tells whether the target can still be reached with grades up to MaxGrade
+	Reachable bool `json:"reachable"`
+}
+
+type simulation struct {
+	class      *parser.ClassGrades
+	hypotheses map[*parser.GradeGroup][]Hypothesis
+	opts       Options
+}
+
+// Simulate applies the hypotheses to the class and solves for the grade needed on the remaining assessments.
+func Simulate(class *parser.ClassGrades, hypotheses []Hypothesis, opts Options) (*Result, error) {
+	s := &simulation{
+		class:      class,
+		hypotheses: make(map[*parser.GradeGroup][]Hypothesis),
+		opts:       opts,
+	}
+
+	if s.opts.Target == 0 {
+		s.opts.Target = PassingGrade
+	}
+
+	if class.HasExam && (s.opts.ExamWeight < 0 || s.opts.ExamWeight > 1) {
+		return nil, fmt.Errorf("invalid exam weight %.2f, must be between 0 and 1", s.opts.ExamWeight)
+	}
+
+	for _, h := range hypotheses {
+		group := s.findGroup(h.Group)
+		if group == nil {
+			return nil, fmt.Errorf("no group %q in class %s, available groups: %s", h.Group, class.Name, s.groupNames())
+		}
+
+		s.hypotheses[group] = append(s.hypotheses[group], h)
+	}
+
+	// every mean is linear in the grade x given to the remaining assessments, so two evaluations are enough
+	f0, _ := s.final(parser.NewGradeValue(0)).Float()
+	f1, _ := s.final(parser.NewGradeValue(1)).Float()
+	slope := f1 - f0
+
+	result := &Result{
+		Class:     class.Name,
+		HasExam:   class.HasExam,
+		Target:    s.opts.Target,
+		Remaining: s.remaining(),
+		Reachable: f0 >= s.opts.Target,
+	}
+
+	x := parser.GradeValue{}
+	if result.Remaining > 0 && slope > 0 {
+		required := math.Max(MinGrade, math.Ceil((s.opts.Target-f0)/slope*10-1e-9)/10)
+		result.Required = parser.NewGradeValue(required)
+		result.Reachable = required <= MaxGrade
+		x = result.Required.Clamp(MinGrade, MaxGrade)
+	}
+
+	result.Groups = s.groups(x)
+	result.Mean = s.mean(x)
+	result.Final = s.final(x)
+	return result, nil
+}
+
+func (s *simulation) findGroup(name string) *parser.GradeGroup {
+	for _, group := range s.class.GradeGroups {
+		if strings.EqualFold(group.Name, name) {
+			return group
+		}
+	}
+
+	return nil
+}
+
+func (s *simulation) groupNames() string {
+	names := make([]string, 0, len(s.class.GradeGroups))
+	for _, group := range s.class.GradeGroups {
+		names = append(names, group.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func (s *simulation) isPending(grade *parser.Grade) bool {
+	return !s.opts.IgnorePending && !grade.Value.IsGraded() && !grade.Value.IsExempt()
+}
+
+func (s *simulation) remaining() int {
+	remaining := 0
+	for _, group := range s.class.GradeGroups {
+		for _, grade := range group.Grades {
+			if s.isPending(grade) {
+				remaining++
+			}
+		}
+
+		for _, h := range s.hypotheses[group] {
+			if !h.Grade.IsGraded() {
+				remaining++
+			}
+		}
+	}
+
+	// an exam without weight doesn't matter
+	if s.class.HasExam && s.opts.ExamWeight > 0 && !s.opts.Exam.IsGraded() {
+		remaining++
+	}
+
+	return remaining
+}
+
+// groupMean computes the mean of a group, giving x to its remaining assessments.
+func (s *simulation) groupMean(group *parser.GradeGroup, x parser.GradeValue) parser.GradeValue {
+	var values []parser.GradeValue
+	var weights []float64
+	for _, grade := range group.Grades {
+		value := grade.Value
+		if s.isPending(grade) {
+			value = x
+		}
+
+		values = append(values, value)
+		weights = append(weights, float64(grade.Weight))
+	}
+
+	for _, h := range s.hypotheses[group] {
+		value := h.Grade
+		if !value.IsGraded() {
+			value = x
+		}
+
+		values = append(values, value)
+		weights = append(weights, h.Weight)
+	}
+
+	return parser.WeightedMean(values, weights)
+}
+
+func (s *simulation) mean(x parser.GradeValue) parser.GradeValue {
+	var values []parser.GradeValue
+	var weights []float64
+	for _, group := range s.class.GradeGroups {
+		values = append(values, s.groupMean(group, x))
+		weights = append(weights, float64(group.Weight))
+	}
+
+	return parser.WeightedMean(values, weights)
+}
+
+func (s *simulation) final(x parser.GradeValue) parser.GradeValue {
+	mean := s.mean(x)
+	if !s.class.HasExam || s.opts.ExamWeight == 0 {
+		return mean
+	}
+
+	exam := s.opts.Exam
+	if !exam.IsGraded() {
+		exam = x
+	}
+
+	// the continuous assessment doesn't matter, it may not even be graded
+	if s.opts.ExamWeight == 1 {
+		return exam
+	}
+
+	return mean.Mul(1 - s.opts.ExamWeight).Add(exam.Mul(s.opts.ExamWeight))
+}
+
+func (s *simulation) groups(x parser.GradeValue) []*GroupResult {
+	groups := make([]*GroupResult, 0, len(s.class.GradeGroups))
+	for _, group := range s.class.GradeGroups {
+		result := &GroupResult{
+			Name:       group.Name,
+			Weight:     group.Weight,
+			Mean:       s.groupMean(group, x),
+			Simulated:  len(s.hypotheses[group]),
+			Hypotheses: s.hypotheses[group],
+		}
+
+		for _, grade := range group.Grades {
+			if s.isPending(grade) {
+				result.Pending++
+			}
+		}
+
+		groups = append(groups, result)
+	}
+
+	return groups
+}
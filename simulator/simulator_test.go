@@ -0,0 +1,162 @@
+package simulator
+
+import (
+	"testing"
+
+	"lutonite.dev/gaps-cli/parser"
+)
+
+// testClass has a graded and a pending test in its course, and a graded lab: its mean is 3.5 + x/4 for a grade
+// x on the pending test.
+func testClass(hasExam bool) *parser.ClassGrades {
+	grade := func(description string, text string, weight float32) *parser.Grade {
+		return &parser.Grade{Description: description, Grade: text, Value: parser.ParseGradeValue(text), Weight: weight}
+	}
+
+	return &parser.ClassGrades{
+		Name:    "ARN",
+		HasExam: hasExam,
+		GradeGroups: []*parser.GradeGroup{
+			{Name: "Cours", Weight: 50, Grades: []*parser.Grade{grade("TE1", "4.0", 50), grade("TE2", "-", 50)}},
+			{Name: "Laboratoire", Weight: 50, Grades: []*parser.Grade{grade("Labo 1", "5.0", 100)}},
+		},
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	tests := []struct {
+		name       string
+		hasExam    bool
+		hypotheses []Hypothesis
+		opts       Options
+
+		remaining int
+		required  string
+		final     string
+		reachable bool
+	}{
+		{
+			name:      "without exam",
+			remaining: 1, required: "2.0", final: "4.00", reachable: true,
+		},
+		{
+			name:    "pending exam",
+			hasExam: true, opts: Options{ExamWeight: 0.5},
+			remaining: 2, required: "3.6", final: "4.00", reachable: true,
+		},
+		{
+			name:    "graded exam",
+			hasExam: true, opts: Options{ExamWeight: 0.5, Exam: parser.NewGradeValue(4)},
+			remaining: 1, required: "2.0", final: "4.00", reachable: true,
+		},
+		{
+			name:      "already reached target",
+			opts:      Options{Target: 3},
+			remaining: 1, required: "1.0", final: "3.75", reachable: true,
+		},
+		{
+			name:      "unreachable target",
+			opts:      Options{Target: 5.9},
+			remaining: 1, required: "9.6", final: "5.00", reachable: false,
+		},
+		{
+			name:      "nothing remaining",
+			opts:      Options{IgnorePending: true},
+			remaining: 0, required: "-", final: "4.50", reachable: true,
+		},
+		{
+			name:      "nothing remaining below target",
+			opts:      Options{IgnorePending: true, Target: 5},
+			remaining: 0, required: "-", final: "4.50", reachable: false,
+		},
+		{
+			name:    "exam weight 0%",
+			hasExam: true, opts: Options{ExamWeight: 0},
+			remaining: 1, required: "2.0", final: "4.00", reachable: true,
+		},
+		{
+			name:    "exam weight 100%",
+			hasExam: true, opts: Options{ExamWeight: 1},
+			remaining: 2, required: "4.0", final: "4.00", reachable: true,
+		},
+		{
+			name:    "exam weight 100% graded",
+			hasExam: true, opts: Options{ExamWeight: 1, Exam: parser.NewGradeValue(3.5)},
+			remaining: 1, required: "-", final: "3.50", reachable: false,
+		},
+		{
+			name:       "hypotheses",
+			hypotheses: []Hypothesis{{Group: "laboratoire", Grade: parser.NewGradeValue(3), Weight: 100}, {Group: "Cours", Weight: 50}},
+			// 0.5 * (4 + 2x) / 3 + 0.5 * 4 reaches 4 for x = 4
+			remaining: 2, required: "4.0", final: "4.00", reachable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Simulate(testClass(tt.hasExam), tt.hypotheses, tt.opts)
+			if err != nil {
+				t.Fatalf("Simulate: %v", err)
+			}
+
+			if result.Remaining != tt.remaining {
+				t.Errorf("remaining = %d, want %d", result.Remaining, tt.remaining)
+			}
+			if got := result.Required.Format(1); got != tt.required {
+				t.Errorf("required = %s, want %s", got, tt.required)
+			}
+			if got := result.Final.Format(2); got != tt.final {
+				t.Errorf("final = %s, want %s", got, tt.final)
+			}
+			if result.Reachable != tt.reachable {
+				t.Errorf("reachable = %t, want %t", result.Reachable, tt.reachable)
+			}
+		})
+	}
+}
+
+func TestSimulateErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		hypotheses []Hypothesis
+		opts       Options
+	}{
+		{"negative exam weight", nil, Options{ExamWeight: -0.1}},
+		{"exam weight above 100%", nil, Options{ExamWeight: 1.5}},
+		{"unknown group", []Hypothesis{{Group: "Projet", Weight: 100}}, Options{ExamWeight: 0.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Simulate(testClass(true), tt.hypotheses, tt.opts); err == nil {
+				t.Error("Simulate succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestParseHypothesis(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    Hypothesis
+		wantErr bool
+	}{
+		{text: "Cours:5.5:50", want: Hypothesis{Group: "Cours", Description: "Simulated", Grade: parser.NewGradeValue(5.5), Weight: 50}},
+		{text: "Cours:?:25%:TE3", want: Hypothesis{Group: "Cours", Description: "TE3", Weight: 25}},
+		{text: "Cours:5.5", wantErr: true},
+		{text: "Cours:abc:50", wantErr: true},
+		{text: "Cours:5:0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseHypothesis(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// migrations are applied in order to bring the database to the current schema version, which is their count.
+// Existing migrations must never be modified, only appended to.
+var migrations = []func(tx *bolt.Tx) error{
+	// 1: initial schema
+	func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{snapshotsBucket, eventsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+	// 2: notification outbox
+	func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{outboxBucket, deadLettersBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
+
+func (s *Store) checkVersion() error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		var version uint64
+		if meta := tx.Bucket(metaBucket); meta != nil && meta.Get(versionKey) != nil {
+			version = binary.BigEndian.Uint64(meta.Get(versionKey))
+		}
+
+		switch {
+		case version > uint64(len(migrations)):
+			return ErrNewerSchema
+		case version < uint64(len(migrations)):
+			return ErrOutdatedSchema
+		default:
+			return nil
+		}
+	})
+}
+
+func (s *Store) migrate() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+
+		var version uint64
+		if value := meta.Get(versionKey); value != nil {
+			version = binary.BigEndian.Uint64(value)
+		}
+
+		if version > uint64(len(migrations)) {
+			return ErrNewerSchema
+		}
+
+		for ; version < uint64(len(migrations)); version++ {
+			if err := migrations[version](tx); err != nil {
+				return fmt.Errorf("history database migration %d failed: %w", version+1, err)
+			}
+		}
+
+		return meta.Put(versionKey, itob(version))
+	})
+}
+
+// ImportFile migrates a JSON history file written by previous gaps-cli versions as the first snapshot of a
+// kind, dated from the file modification time. The file is renamed with a .migrated suffix once imported.
+// Nothing is done when the file doesn't exist or the kind already has snapshots.
+func (s *Store) ImportFile(kind Kind, path string) (bool, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	var existing json.RawMessage
+	if found, err := s.Latest(kind, &existing); found || err != nil {
+		return false, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	var v json.RawMessage
+	if err = json.Unmarshal(data, &v); err != nil {
+		return false, fmt.Errorf("couldn't migrate %s: %w", path, err)
+	}
+
+	if _, err = s.Save(kind, info.ModTime(), v); err != nil {
+		return false, err
+	}
+
+	return true, os.Rename(path, path+".migrated")
+}
@@ -0,0 +1,209 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"lutonite.dev/gaps-cli/notifier"
+)
+
+var (
+	outboxBucket      = []byte("outbox")
+	deadLettersBucket = []byte("dead-letters")
+)
+
+// Delivery is the pending notification of an event to a single notifier.
+type Delivery struct {
+	Id          uint64          `json:"id"`
+	EventId     uint64          `json:"eventId"`
+	Notifier    string          `json:"notifier"`
+	Event       *notifier.Event `json:"event"`
+	Attempts    int             `json:"attempts"`
+	NextAttempt time.Time       `json:"nextAttempt"`
+	LastError   string          `json:"lastError,omitempty"`
+	Dead        bool            `json:"dead"`
+}
+
+// Commit atomically stores a snapshot along with the events detected while comparing it to the previous one,
+// and queues their delivery to each of the notifiers. Either everything is stored or nothing is, so events are
+// never lost nor detected twice. It reports whether a new snapshot was saved, see Save.
+func (s *Store) Commit(kind Kind, at time.Time, v any, events []*notifier.Event, notifiers []string) (bool, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return false, err
+	}
+
+	saved := false
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		if saved, err = saveSnapshot(tx, kind, at, data); err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			eventId, err := addEvent(tx, event)
+			if err != nil {
+				return err
+			}
+
+			for _, name := range notifiers {
+				if err := putDelivery(tx.Bucket(outboxBucket), &Delivery{
+					EventId:     eventId,
+					Notifier:    name,
+					Event:       event,
+					NextAttempt: at,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+
+	return saved, err
+}
+
+// Due returns the pending deliveries whose next attempt is before now, in the order they were queued.
+func (s *Store) Due(now time.Time) ([]*Delivery, error) {
+	var due []*Delivery
+	err := s.Deliveries(false, func(d *Delivery) error {
+		if !d.NextAttempt.After(now) {
+			due = append(due, d)
+		}
+
+		return nil
+	})
+
+	return due, err
+}
+
+// Deliveries calls fn for each pending delivery, or for each dead letter when dead is set.
+func (s *Store) Deliveries(dead bool, fn func(d *Delivery) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return deliveries(tx, dead).ForEach(func(_, value []byte) error {
+			var d Delivery
+			if err := json.Unmarshal(value, &d); err != nil {
+				return err
+			}
+
+			return fn(&d)
+		})
+	})
+}
+
+// Delivered removes a successful delivery from the outbox.
+func (s *Store) Delivered(id uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(outboxBucket).Delete(itob(id))
+	})
+}
+
+// Failed records a failed delivery attempt, retrying it at next or moving it to the dead letters when next is
+// zero.
+func (s *Store) Failed(d *Delivery, cause error, next time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(outboxBucket).Delete(itob(d.Id)); err != nil {
+			return err
+		}
+
+		d.Attempts++
+		d.LastError = cause.Error()
+		d.NextAttempt = next
+		if next.IsZero() {
+			d.Dead = true
+			return putDelivery(tx.Bucket(deadLettersBucket), d)
+		}
+
+		data, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(outboxBucket).Put(itob(d.Id), data)
+	})
+}
+
+// Replay moves dead letters back to the outbox for an immediate new attempt, every dead letter being replayed
+// when no id is given. It returns the number of replayed deliveries.
+func (s *Store) Replay(ids ...uint64) (int, error) {
+	replayed := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		dead := tx.Bucket(deadLettersBucket)
+		if len(ids) == 0 {
+			_ = dead.ForEach(func(key, _ []byte) error {
+				ids = append(ids, btoi(key))
+				return nil
+			})
+		}
+
+		for _, id := range ids {
+			value := dead.Get(itob(id))
+			if value == nil {
+				return fmt.Errorf("no dead letter with id %d", id)
+			}
+
+			var d Delivery
+			if err := json.Unmarshal(value, &d); err != nil {
+				return err
+			}
+			if err := dead.Delete(itob(id)); err != nil {
+				return err
+			}
+
+			d.Attempts = 0
+			d.Dead = false
+			d.NextAttempt = time.Now()
+			if err := putDelivery(tx.Bucket(outboxBucket), &d); err != nil {
+				return err
+			}
+			replayed++
+		}
+
+		return nil
+	})
+
+	return replayed, err
+}
+
+// Drop deletes deliveries, from the dead letters when dead is set or from the pending ones otherwise.
+func (s *Store) Drop(dead bool, ids ...uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := deliveries(tx, dead)
+		for _, id := range ids {
+			if b.Get(itob(id)) == nil {
+				return fmt.Errorf("no delivery with id %d", id)
+			}
+			if err := b.Delete(itob(id)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func deliveries(tx *bolt.Tx, dead bool) *bolt.Bucket {
+	if dead {
+		return tx.Bucket(deadLettersBucket)
+	}
+
+	return tx.Bucket(outboxBucket)
+}
+
+// putDelivery stores a delivery under a new id, so moved deliveries keep being processed in order.
+func putDelivery(b *bolt.Bucket, d *Delivery) error {
+	id, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	d.Id = id
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	return b.Put(itob(d.Id), data)
+}
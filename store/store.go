@@ -0,0 +1,235 @@
+// Package store persists the scraper snapshots and the change events detected between them in an embedded
+// bbolt database, so the history survives crashes and can be queried afterwards.
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"lutonite.dev/gaps-cli/notifier"
+)
+
+// Kind identifies what a snapshot contains.
+type Kind string
+
+const (
+	Grades     Kind = "grades"
+	Absences   Kind = "absences"
+	ReportCard Kind = "report-card"
+	Schedule   Kind = "schedule"
+)
+
+var Kinds = []Kind{Grades, Absences, ReportCard, Schedule}
+
+var (
+	metaBucket      = []byte("meta")
+	snapshotsBucket = []byte("snapshots")
+	eventsBucket    = []byte("events")
+
+	versionKey = []byte("version")
+)
+
+// ErrNewerSchema is returned when the database was written by a more recent gaps-cli version.
+var ErrNewerSchema = errors.New("the history database was created by a newer version of gaps-cli")
+
+// ErrOutdatedSchema is returned when a database opened read-only still needs to be migrated.
+var ErrOutdatedSchema = errors.New("the history database needs to be migrated, run the scraper once to upgrade it")
+
+// Snapshot is the content of a GAPS page at a given time, Data being the JSON encoding of the parsed page.
+type Snapshot struct {
+	Id   uint64          `json:"id"`
+	Kind Kind            `json:"kind"`
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Decode unmarshals the snapshot data into v.
+func (s *Snapshot) Decode(v any) error {
+	return json.Unmarshal(s.Data, v)
+}
+
+// EventRecord is a change event along with the time it was stored.
+type EventRecord struct {
+	Id    uint64          `json:"id"`
+	Event *notifier.Event `json:"event"`
+}
+
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the database at path and applies the pending migrations.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open history database %s: %w", path, err)
+	}
+
+	s := &Store{db: db}
+	if err = s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// OpenReadOnly opens an existing database for reading, it fails when its schema is not up-to-date. The error
+// wraps os.ErrNotExist when there is no database at path.
+func OpenReadOnly(path string) (*Store, error) {
+	// bolt can't create a database read-only and fails with a confusing error
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("couldn't open history database %s: %w", path, err)
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open history database %s: %w", path, err)
+	}
+
+	s := &Store{db: db}
+	if err = s.checkVersion(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Latest decodes the most recent snapshot of a kind into v, reporting whether there was one.
+func (s *Store) Latest(kind Kind, v any) (bool, error) {
+	var data []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if b := snapshots(tx, kind); b != nil {
+			if _, value := b.Cursor().Last(); value != nil {
+				var snapshot Snapshot
+				if err := json.Unmarshal(value, &snapshot); err != nil {
+					return err
+				}
+				data = snapshot.Data
+			}
+		}
+
+		return nil
+	})
+	if err != nil || data == nil {
+		return false, err
+	}
+
+	return true, json.Unmarshal(data, v)
+}
+
+// Save stores v as a new snapshot of a kind, unless it is identical to the latest one. It reports whether a
+// snapshot was added.
+func (s *Store) Save(kind Kind, at time.Time, v any) (bool, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return false, err
+	}
+
+	saved := false
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		saved, err = saveSnapshot(tx, kind, at, data)
+		return err
+	})
+
+	return saved, err
+}
+
+func saveSnapshot(tx *bolt.Tx, kind Kind, at time.Time, data []byte) (bool, error) {
+	b, err := tx.Bucket(snapshotsBucket).CreateBucketIfNotExists([]byte(kind))
+	if err != nil {
+		return false, err
+	}
+
+	if _, value := b.Cursor().Last(); value != nil {
+		var latest Snapshot
+		if err := json.Unmarshal(value, &latest); err == nil && bytes.Equal(latest.Data, data) {
+			return false, nil
+		}
+	}
+
+	id, _ := b.NextSequence()
+	value, err := json.Marshal(&Snapshot{Id: id, Kind: kind, Time: at, Data: data})
+	if err != nil {
+		return false, err
+	}
+
+	return true, b.Put(itob(id), value)
+}
+
+// Snapshots calls fn for every snapshot of a kind, from the oldest to the most recent.
+func (s *Store) Snapshots(kind Kind, fn func(snapshot *Snapshot) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		b := snapshots(tx, kind)
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(_, value []byte) error {
+			var snapshot Snapshot
+			if err := json.Unmarshal(value, &snapshot); err != nil {
+				return err
+			}
+
+			return fn(&snapshot)
+		})
+	})
+}
+
+// AddEvent records a change event.
+func (s *Store) AddEvent(event *notifier.Event) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		_, err := addEvent(tx, event)
+		return err
+	})
+}
+
+func addEvent(tx *bolt.Tx, event *notifier.Event) (uint64, error) {
+	b := tx.Bucket(eventsBucket)
+	id, _ := b.NextSequence()
+	value, err := json.Marshal(&EventRecord{Id: id, Event: event})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, b.Put(itob(id), value)
+}
+
+// Events calls fn for every recorded event, from the oldest to the most recent.
+func (s *Store) Events(fn func(record *EventRecord) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(eventsBucket).ForEach(func(_, value []byte) error {
+			var record EventRecord
+			if err := json.Unmarshal(value, &record); err != nil {
+				return err
+			}
+
+			return fn(&record)
+		})
+	})
+}
+
+func snapshots(tx *bolt.Tx, kind Kind) *bolt.Bucket {
+	return tx.Bucket(snapshotsBucket).Bucket([]byte(kind))
+}
+
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+func btoi(b []byte) uint64 {
+	return binary.BigEndian.Uint64(b)
+}
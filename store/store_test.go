@@ -0,0 +1,304 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"lutonite.dev/gaps-cli/notifier"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	s, err := Open(t.TempDir() + "/history.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+func gradeEvent(name string) *notifier.Event {
+	return &notifier.Event{
+		Type:  notifier.GradeEvent,
+		Time:  time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		Grade: &notifier.GradeChange{Course: "ARN", Name: name, Grade: "5.0"},
+	}
+}
+
+func countEvents(t *testing.T, s *Store) int {
+	t.Helper()
+
+	count := 0
+	if err := s.Events(func(*EventRecord) error { count++; return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	return count
+}
+
+func TestSave(t *testing.T) {
+	s := openTestStore(t)
+	at := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	for i, tt := range []struct {
+		value []string
+		saved bool
+	}{
+		{[]string{"TE1"}, true},
+		{[]string{"TE1"}, false},
+		{[]string{"TE1", "TE2"}, true},
+	} {
+		saved, err := s.Save(Grades, at.Add(time.Duration(i)*time.Hour), tt.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved != tt.saved {
+			t.Errorf("Save(%v) = %t, want %t", tt.value, saved, tt.saved)
+		}
+	}
+
+	var latest []string
+	if found, err := s.Latest(Grades, &latest); !found || err != nil || len(latest) != 2 {
+		t.Errorf("Latest() = %v, %t, %v, want the last snapshot", latest, found, err)
+	}
+	if found, err := s.Latest(Absences, &latest); found || err != nil {
+		t.Errorf("Latest() of a kind without snapshot = %t, %v", found, err)
+	}
+}
+
+func TestCommit(t *testing.T) {
+	s := openTestStore(t)
+	at := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	saved, err := s.Commit(Grades, at, []string{"TE1", "TE2"}, []*notifier.Event{gradeEvent("TE1"), gradeEvent("TE2")}, []string{"ntfy", "webhook"})
+	if err != nil || !saved {
+		t.Fatalf("Commit() = %t, %v", saved, err)
+	}
+
+	if count := countEvents(t, s); count != 2 {
+		t.Errorf("%d events stored, want 2", count)
+	}
+
+	due, err := s.Due(at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 4 {
+		t.Fatalf("%d deliveries due, want one per event and notifier", len(due))
+	}
+	if due[0].Notifier != "ntfy" || due[1].Notifier != "webhook" || due[0].Event.Grade.Name != "TE1" || due[2].Event.Grade.Name != "TE2" {
+		t.Errorf("deliveries are not in the order they were queued")
+	}
+	if due, _ := s.Due(at.Add(-time.Minute)); len(due) != 0 {
+		t.Errorf("%d deliveries due before they were queued", len(due))
+	}
+}
+
+func TestCommitAtomic(t *testing.T) {
+	s := openTestStore(t)
+	at := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	// the second event can't be encoded, failing the transaction after the snapshot and the first event
+	invalid := gradeEvent("TE2")
+	invalid.Time = time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := s.Commit(Grades, at, []string{"TE1", "TE2"}, []*notifier.Event{gradeEvent("TE1"), invalid}, []string{"ntfy"}); err == nil {
+		t.Fatal("Commit() of an invalid event succeeded")
+	}
+
+	var latest []string
+	if found, _ := s.Latest(Grades, &latest); found {
+		t.Errorf("snapshot %v stored by a failed commit", latest)
+	}
+	if count := countEvents(t, s); count != 0 {
+		t.Errorf("%d events stored by a failed commit", count)
+	}
+	if due, _ := s.Due(at); len(due) != 0 {
+		t.Errorf("%d deliveries queued by a failed commit", len(due))
+	}
+}
+
+func TestOutbox(t *testing.T) {
+	s := openTestStore(t)
+	at := time.Now()
+
+	if _, err := s.Commit(Grades, at, []string{"TE1"}, []*notifier.Event{gradeEvent("TE1"), gradeEvent("TE2")}, []string{"ntfy"}); err != nil {
+		t.Fatal(err)
+	}
+	due, err := s.Due(at)
+	if err != nil || len(due) != 2 {
+		t.Fatalf("Due() = %d, %v, want 2 deliveries", len(due), err)
+	}
+
+	// a retried delivery is only due at its next attempt
+	retry := at.Add(time.Minute)
+	if err := s.Failed(due[0], errors.New("unavailable"), retry); err != nil {
+		t.Fatal(err)
+	}
+	if now, _ := s.Due(at); len(now) != 1 || now[0].Event.Grade.Name != "TE2" {
+		t.Errorf("Due() after a failure = %d deliveries, want only TE2", len(now))
+	}
+	later, _ := s.Due(retry)
+	if len(later) != 2 || later[0].Attempts != 1 || later[0].LastError != "unavailable" {
+		t.Fatalf("Due() at the retry = %+v, want the failed delivery with its attempt", later)
+	}
+
+	if err := s.Delivered(later[1].Id); err != nil {
+		t.Fatal(err)
+	}
+
+	// a delivery failing for good becomes a dead letter
+	if err := s.Failed(later[0], errors.New("gone"), time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := s.Due(retry); len(pending) != 0 {
+		t.Fatalf("%d deliveries still pending", len(pending))
+	}
+
+	var dead []*Delivery
+	if err := s.Deliveries(true, func(d *Delivery) error { dead = append(dead, d); return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || !dead[0].Dead || dead[0].Attempts != 2 || dead[0].LastError != "gone" {
+		t.Fatalf("dead letters = %+v, want the failed delivery", dead)
+	}
+
+	// replayed dead letters are retried right away from scratch
+	if _, err := s.Replay(dead[0].Id + 1); err == nil {
+		t.Error("Replay() of an unknown id succeeded")
+	}
+	if replayed, err := s.Replay(); err != nil || replayed != 1 {
+		t.Fatalf("Replay() = %d, %v, want 1", replayed, err)
+	}
+	pending, _ := s.Due(time.Now())
+	if len(pending) != 1 || pending[0].Dead || pending[0].Attempts != 0 || pending[0].Event.Grade.Name != "TE1" {
+		t.Fatalf("pending after replay = %+v, want the replayed delivery", pending)
+	}
+
+	if err := s.Drop(true, pending[0].Id); err == nil {
+		t.Error("Drop() of a pending delivery from the dead letters succeeded")
+	}
+	if err := s.Drop(false, pending[0].Id); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := s.Due(time.Now()); len(pending) != 0 {
+		t.Errorf("%d deliveries pending after drop", len(pending))
+	}
+}
+
+// writeVersion creates a database at path with the given schema version, applying its migrations.
+func writeVersion(t *testing.T, path string, version int) {
+	t.Helper()
+
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for i := 0; i < version && i < len(migrations); i++ {
+			if err := migrations[i](tx); err != nil {
+				return err
+			}
+		}
+
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		return meta.Put(versionKey, itob(uint64(version)))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	path := t.TempDir() + "/history.db"
+	writeVersion(t, path, 1)
+
+	if _, err := OpenReadOnly(path); !errors.Is(err, ErrOutdatedSchema) {
+		t.Errorf("OpenReadOnly() of an outdated database = %v, want %v", err, ErrOutdatedSchema)
+	}
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Due(time.Now()); err != nil {
+		t.Errorf("outbox not created by the migration: %v", err)
+	}
+	s.Close()
+
+	s, err = OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("OpenReadOnly() of a migrated database = %v", err)
+	}
+	s.Close()
+
+	if _, err := OpenReadOnly(t.TempDir() + "/missing.db"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("OpenReadOnly() of a missing database = %v, want %v", err, os.ErrNotExist)
+	}
+
+	newer := t.TempDir() + "/history.db"
+	writeVersion(t, newer, len(migrations)+1)
+	if _, err := Open(newer); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("Open() of a newer database = %v, want %v", err, ErrNewerSchema)
+	}
+}
+
+func TestImportFile(t *testing.T) {
+	s := openTestStore(t)
+	dir := t.TempDir()
+
+	if imported, err := s.ImportFile(Grades, dir+"/missing.json"); imported || err != nil {
+		t.Errorf("ImportFile() of a missing file = %t, %v", imported, err)
+	}
+
+	invalid := dir + "/invalid.json"
+	if err := os.WriteFile(invalid, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ImportFile(Grades, invalid); err == nil {
+		t.Error("ImportFile() of an invalid file succeeded")
+	}
+
+	legacy := dir + "/grades.json"
+	if err := os.WriteFile(legacy, []byte(`{"ARN":{"TE1":{"grade":"5.0"}}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(legacy, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	if imported, err := s.ImportFile(Grades, legacy); !imported || err != nil {
+		t.Fatalf("ImportFile() = %t, %v", imported, err)
+	}
+	if _, err := os.Stat(legacy + ".migrated"); err != nil {
+		t.Errorf("imported file not renamed: %v", err)
+	}
+
+	var snapshots []*Snapshot
+	_ = s.Snapshots(Grades, func(snapshot *Snapshot) error {
+		snapshots = append(snapshots, snapshot)
+		return nil
+	})
+	if len(snapshots) != 1 || !snapshots[0].Time.Equal(modTime) || !json.Valid(snapshots[0].Data) {
+		t.Fatalf("snapshots = %+v, want the imported file dated from its modification", snapshots)
+	}
+
+	// a kind with snapshots is never overwritten
+	if err := os.WriteFile(legacy, []byte(`{}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if imported, err := s.ImportFile(Grades, legacy); imported || err != nil {
+		t.Errorf("ImportFile() over existing snapshots = %t, %v", imported, err)
+	}
+}